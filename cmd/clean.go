@@ -5,6 +5,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
@@ -14,7 +16,7 @@ import (
 
 const cleanLongDescription = `Use this command to clean resources used for building the package.
 
-The command will remove built package files (in build/), files needed for managing the development stack (in ~/.elastic-package/stack/development) and stack service logs (in ~/.elastic-package/tmp/service_logs).`
+The command will remove built package files (in build/), files needed for managing the development stack (in ~/.elastic-package/stack/development), stack service logs (in ~/.elastic-package/tmp/service_logs) and namespaces left over by system tests in the kind cluster (older than one hour). Terraform workspaces kept by system tests (--keep-terraform-state) are reported, but not removed, as their infrastructure needs to be destroyed first.`
 
 func setupCleanCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -55,6 +57,22 @@ func cleanCommandAction(cmd *cobra.Command, args []string) error {
 		cmd.Printf("Temporary service logs removed: %s\n", target)
 	}
 
+	namespaces, err := cleanup.KubernetesNamespaces()
+	if err != nil {
+		return errors.Wrap(err, "can't clean Kubernetes test namespaces")
+	}
+	if len(namespaces) > 0 {
+		cmd.Printf("Kubernetes test namespaces removed: %s\n", strings.Join(namespaces, ", "))
+	}
+
+	workspaces, err := cleanup.TerraformWorkspaces()
+	if err != nil {
+		return errors.Wrap(err, "can't find kept Terraform workspaces")
+	}
+	for _, workspace := range workspaces {
+		cmd.Printf("Warning: Terraform workspace kept, its infrastructure may not be destroyed (remove it after running \"terraform destroy\" there): %s\n", workspace)
+	}
+
 	cmd.Println("Done")
 	return nil
 }
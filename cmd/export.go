@@ -6,6 +6,8 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pkg/errors"
@@ -15,13 +17,22 @@ import (
 	"github.com/elastic/elastic-package/internal/common"
 	"github.com/elastic/elastic-package/internal/export"
 	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
 )
 
 const exportLongDescription = `Use this command to export assets relevant for the package, e.g. Kibana dashboards.`
 
 const exportDashboardsLongDescription = `Use this command to export dashboards with referenced objects from the Kibana instance.
 
-Use this command to download selected dashboards and other associated saved objects from Kibana. This command adjusts the downloaded saved objects according to package naming conventions (prefixes, unique IDs) and writes them locally into folders corresponding to saved object types (dashboard, visualization, map, etc.).`
+Use this command to download selected dashboards and other associated saved objects from Kibana. This command adjusts the downloaded saved objects according to package naming conventions (prefixes, unique IDs) and writes them locally into folders corresponding to saved object types (dashboard, visualization, map, etc.).
+
+Saved objects exported from a newer Kibana can't be loaded by older Kibana versions allowed by the package (conditions.kibana.version). The command warns about objects migrated by Kibana newer than the minimum version required by the package. Use the --target-version flag to export dashboards from the Elastic stack in the given version, booted up with the selected profile, or reused if it's already running in this version. The command fails if the stack is running in another version, so it's never recreated with unsaved changes. The stack is left running, so dashboards can be prepared there and exported again.`
+
+const exportSavedObjectsLongDescription = `Use this command to export saved objects of the selected type from the Kibana instance.
+
+Supported types are saved searches, visualizations, Lens visualizations, maps, ML modules, tags and dashboards. Use the --with-references flag to export also all objects referenced by selected ones. Index patterns are skipped, as they're usually provided by Fleet, unless the --with-index-patterns flag is used or index patterns are exported explicitly (--type index-pattern). Exported saved objects are adjusted according to package naming conventions and written into folders corresponding to saved object types.`
 
 func setupExportCommand() *cobraext.Command {
 	exportDashboardCmd := &cobra.Command{
@@ -31,6 +42,19 @@ func setupExportCommand() *cobraext.Command {
 		RunE:  exportDashboardsCmd,
 	}
 	exportDashboardCmd.Flags().StringSliceP(cobraext.DashboardIDsFlagName, "d", nil, cobraext.DashboardIDsFlagDescription)
+	exportDashboardCmd.Flags().String(cobraext.TargetVersionFlagName, "", cobraext.TargetVersionFlagDescription)
+	exportDashboardCmd.Flags().StringP(cobraext.ProfileFlagName, "p", lookupEnv(), fmt.Sprintf(cobraext.ProfileFlagDescription, profileNameEnvVar))
+
+	exportSavedObjectsCmd := &cobra.Command{
+		Use:   "saved-objects",
+		Short: "Export saved objects from Kibana",
+		Long:  exportSavedObjectsLongDescription,
+		RunE:  exportSavedObjectsCmd,
+	}
+	exportSavedObjectsCmd.Flags().String(cobraext.SavedObjectTypeFlagName, "", fmt.Sprintf(cobraext.SavedObjectTypeFlagDescription, strings.Join(export.SupportedSavedObjectTypes, ", ")))
+	exportSavedObjectsCmd.Flags().StringSlice(cobraext.SavedObjectIDsFlagName, nil, cobraext.SavedObjectIDsFlagDescription)
+	exportSavedObjectsCmd.Flags().Bool(cobraext.WithReferencesFlagName, false, cobraext.WithReferencesFlagDescription)
+	exportSavedObjectsCmd.Flags().Bool(cobraext.WithIndexPatternsFlagName, false, cobraext.WithIndexPatternsFlagDescription)
 
 	cmd := &cobra.Command{
 		Use:   "export",
@@ -38,6 +62,7 @@ func setupExportCommand() *cobraext.Command {
 		Long:  exportLongDescription,
 	}
 	cmd.AddCommand(exportDashboardCmd)
+	cmd.AddCommand(exportSavedObjectsCmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
@@ -52,6 +77,23 @@ func exportDashboardsCmd(cmd *cobra.Command, args []string) error {
 
 	common.TrimStringSlice(dashboardIDs)
 
+	targetVersion, err := cmd.Flags().GetString(cobraext.TargetVersionFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.TargetVersionFlagName)
+	}
+
+	if targetVersion != "" {
+		profileName, err := cmd.Flags().GetString(cobraext.ProfileFlagName)
+		if err != nil {
+			return cobraext.FlagParsingError(err, cobraext.ProfileFlagName)
+		}
+
+		err = useStackInVersion(cmd, profileName, targetVersion)
+		if err != nil {
+			return errors.Wrapf(err, "can't use the Elastic stack %s", targetVersion)
+		}
+	}
+
 	kibanaClient, err := kibana.NewClient()
 	if err != nil {
 		return errors.Wrap(err, "can't create Kibana client")
@@ -78,6 +120,155 @@ func exportDashboardsCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// useStackInVersion boots up the Elastic stack of the profile in the given version, unless Kibana is already
+// running in this version, and sets environment variables to use the stack. The stack running in another
+// version isn't touched, as it may contain unsaved changes.
+func useStackInVersion(cmd *cobra.Command, profileName, stackVersion string) error {
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return errors.Wrap(err, "locating package root failed")
+	}
+
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return errors.Wrapf(err, "reading package manifest failed (path: %s)", packageRoot)
+	}
+
+	err = packages.CheckConditions(*m, []string{"kibana.version=" + stackVersion})
+	if err != nil {
+		return errors.Wrap(err, "target version isn't supported by the package")
+	}
+
+	usrProfile, err := profile.LoadProfile(profileName)
+	if err != nil {
+		return errors.Wrap(err, "error loading profile")
+	}
+
+	profileConfig, err := usrProfile.Config()
+	if err != nil {
+		return errors.Wrap(err, "can't read profile configuration")
+	}
+	if profileConfig.IsRemote() {
+		return fmt.Errorf("target version can't be selected for the remote profile %s", profileName)
+	}
+
+	stackOptions := stack.Options{
+		DaemonMode:   true,
+		StackVersion: stackVersion,
+		Profile:      usrProfile,
+	}
+
+	running, kibanaVersion, err := stackStatus(stackOptions)
+	if err != nil {
+		return err
+	}
+	switch {
+	case running && sameStackVersion(kibanaVersion, stackVersion):
+		cmd.Printf("Kibana %s is already running\n", stackVersion)
+	case running:
+		if kibanaVersion == "" {
+			kibanaVersion = "unknown"
+		}
+		return fmt.Errorf("the Elastic stack is already running in another version (Kibana: %s), take it down with \"elastic-package stack down\" "+
+			"after saving your changes, or export dashboards without the --target-version flag", kibanaVersion)
+	default:
+		cmd.Printf("Boot up the Elastic stack %s\n", stackVersion)
+		err = stack.BootUp(stackOptions)
+		if err != nil {
+			return errors.Wrap(err, "booting up the stack failed")
+		}
+	}
+
+	env, err := stack.Environment(usrProfile)
+	if err != nil {
+		return errors.Wrap(err, "can't read stack environment variables")
+	}
+	for k, v := range env {
+		err = os.Setenv(k, v)
+		if err != nil {
+			return errors.Wrapf(err, "can't set environment variable %s", k)
+		}
+	}
+	return nil
+}
+
+// stackStatus checks if any service of the Elastic stack is running, and returns the version of Kibana,
+// if it's running and healthy.
+func stackStatus(options stack.Options) (bool, string, error) {
+	statuses, err := stack.Status(options)
+	if err != nil {
+		return false, "", errors.Wrap(err, "can't read status of the stack")
+	}
+
+	var running bool
+	var kibanaVersion string
+	for _, status := range statuses {
+		if status.State != stack.ServiceStateNotCreated {
+			running = true
+		}
+		if status.Name == "kibana" && status.Healthy() {
+			kibanaVersion = status.Version
+		}
+	}
+	return running, kibanaVersion, nil
+}
+
+func sameStackVersion(a, b string) bool {
+	return strings.TrimSuffix(a, "-SNAPSHOT") == strings.TrimSuffix(b, "-SNAPSHOT")
+}
+
+func exportSavedObjectsCmd(cmd *cobra.Command, args []string) error {
+	cmd.Println("Export Kibana saved objects")
+
+	objectType, err := cmd.Flags().GetString(cobraext.SavedObjectTypeFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.SavedObjectTypeFlagName)
+	}
+
+	err = export.ValidateSavedObjectType(objectType)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.SavedObjectTypeFlagName)
+	}
+
+	ids, err := cmd.Flags().GetStringSlice(cobraext.SavedObjectIDsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.SavedObjectIDsFlagName)
+	}
+
+	common.TrimStringSlice(ids)
+	if len(ids) == 0 {
+		return cobraext.FlagParsingError(errors.New("at least one saved object ID is required"), cobraext.SavedObjectIDsFlagName)
+	}
+
+	withReferences, err := cmd.Flags().GetBool(cobraext.WithReferencesFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.WithReferencesFlagName)
+	}
+
+	withIndexPatterns, err := cmd.Flags().GetBool(cobraext.WithIndexPatternsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.WithIndexPatternsFlagName)
+	}
+
+	kibanaClient, err := kibana.NewClient()
+	if err != nil {
+		return errors.Wrap(err, "can't create Kibana client")
+	}
+
+	err = export.SavedObjects(kibanaClient, export.SavedObjectsOptions{
+		Type:              objectType,
+		IDs:               ids,
+		WithReferences:    withReferences,
+		WithIndexPatterns: withIndexPatterns,
+	})
+	if err != nil {
+		return errors.Wrap(err, "saved objects export failed")
+	}
+
+	cmd.Println("Done")
+	return nil
+}
+
 func promptDashboardIDs(kibanaClient *kibana.Client) ([]string, error) {
 	savedDashboards, err := kibanaClient.FindDashboards()
 	if err != nil {
@@ -5,17 +5,20 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/packages/installer"
+	"github.com/elastic/elastic-package/internal/profile"
 )
 
 const installLongDescription = `Use this command to install the package in Kibana.
 
-The command uses Kibana API to install the package in Kibana. The package must be exposed via the Package Registry.`
+The command uses Kibana API to install the package in Kibana. The package must be exposed via the Package Registry. If the selected profile targets the remote stack (profile of the remote type), the package is built and uploaded to Kibana instead.`
 
 func setupInstallCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -25,6 +28,7 @@ func setupInstallCommand() *cobraext.Command {
 		RunE:  installCommandAction,
 	}
 	cmd.Flags().StringSliceP(cobraext.CheckConditionFlagName, "c", nil, cobraext.CheckConditionFlagDescription)
+	cmd.Flags().StringP(cobraext.ProfileFlagName, "p", lookupEnv(), fmt.Sprintf(cobraext.ProfileFlagDescription, profileNameEnvVar))
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
@@ -59,7 +63,17 @@ func installCommandAction(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	packageInstaller, err := installer.CreateForManifest(*m)
+	profileName, err := cmd.Flags().GetString(cobraext.ProfileFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.ProfileFlagName)
+	}
+
+	usrProfile, err := profile.LoadProfile(profileName)
+	if err != nil {
+		return errors.Wrap(err, "error loading profile")
+	}
+
+	packageInstaller, err := installer.CreateForManifest(*m, usrProfile)
 	if err != nil {
 		return errors.Wrap(err, "can't create the package installer")
 	}
@@ -12,12 +12,15 @@ import (
 
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/docs"
+	"github.com/elastic/elastic-package/internal/export"
 	"github.com/elastic/elastic-package/internal/packages"
 )
 
 const lintLongDescription = `Use this command to validate the contents of a package using the package specification (see: https://github.com/elastic/package-spec).
 
-The command ensures that the package is aligned with the package spec and the README file is up-to-date with its template (if present).`
+The command ensures that the package is aligned with the package spec and the README file is up-to-date with its template (if present).
+
+Kibana saved objects of the package are linted too. Errors are reported for references to objects (including index patterns) not shipped by the package or by Fleet, index patterns hard-coded in search sources, dashboard titles without the "[Package] " prefix, absolute URLs and links to missing dashboards in markdown, and IDs not standardized according to package naming conventions. Fields used by visualizations and saved searches, which aren't defined in any data stream, and saved objects migrated by Kibana newer than the minimum version required by the package (conditions.kibana.version) are reported as warnings.`
 
 func setupLintCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -59,6 +62,17 @@ func lintCommandAction(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "linting package failed")
 	}
 
+	result, err := export.LintSavedObjects(packageRootPath)
+	if err != nil {
+		return errors.Wrap(err, "linting saved objects failed")
+	}
+	for _, warning := range result.Warnings {
+		cmd.Printf("Warning: %s\n", warning)
+	}
+	if len(result.Errors) > 0 {
+		return errors.Wrap(result.Errors, "linting saved objects failed")
+	}
+
 	cmd.Println("Done")
 	return nil
 }
@@ -16,17 +16,28 @@ import (
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/configuration/locations"
 	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
 )
 
 // profileNameEnvVar is the name of the environment variable to set the default profile
-const profileNameEnvVar = "ELASTIC_PACKAGE_PROFILE"
+var profileNameEnvVar = stack.ProfileNameEnv
 
 func setupProfilesCommand() *cobraext.Command {
 	profilesLongDescription := `Use this command to add, remove, and manage multiple config profiles.
 	
 Individual user profiles appear in ~/.elastic-package/stack, and contain all the config files needed by the "stack" subcommand. 
 Once a new profile is created, it can be specified with the -p flag, or the ELASTIC_PACKAGE_PROFILE environment variable.
-User profiles are not overwritten on upgrade of elastic-stack, and can be freely modified to allow for different stack configs.`
+User profiles are not overwritten on upgrade of elastic-stack, and can be freely modified to allow for different stack configs.
+
+Stack services can be customized in the config.yml file of the profile: Elasticsearch heap size and number of nodes, additional Elasticsearch and Kibana settings, number of Elastic Agents, and additional side-car services (e.g. Logstash). Side-car services can be selected with the --services flag of the "stack up" subcommand.
+
+Every profile has its own certificate authority and certificates of stack services, generated at profile creation in the certs directory of the profile. The key of the certificate authority is stored outside of this directory, and every service container gets only the CA certificate and its own certificate. Setting "stack.tls.enabled: true" in config.yml serves Elasticsearch, Kibana and Fleet Server over HTTPS. The path to the CA certificate is exported by "stack shellinit" and trusted by elastic-package commands.
+
+Profiles of the remote type ("profiles create --type remote") target an existing deployment (e.g. Elastic Cloud) instead of booting up the stack locally. URLs of Elasticsearch, Kibana and Fleet Server, and credentials (API key or username and password) are defined in config.yml of the profile. Once loaded with "stack shellinit", which exports also the name of the profile, commands like "install", "test" and "export" work against the remote stack, and packages are uploaded to Kibana. "stack up" starts only the Elastic Agent used by system tests, enrolled into the remote Fleet.
+
+Profiles can be shared using the "export" and "import" subcommands (certificates aren't exported, they are generated again on import), and compared using the "diff" subcommand.
+
+Files of profiles which differ from defaults of the current elastic-package version can be found using the "check" subcommand. The "upgrade" subcommand updates them, merging changes done by the user with changes of defaults, as long as they don't conflict. Defaults used to create the profile are kept in its defaults directory, and are copied with the profile by "create --from", "export" and "import". Files of profiles created before, whose previous defaults are unknown, are reported as modified; once their differences are reviewed, they can be replaced with current defaults using "upgrade --overwrite-modified".`
 
 	profileCommand := &cobra.Command{
 		Use:   "profiles",
@@ -48,9 +59,15 @@ User profiles are not overwritten on upgrade of elastic-stack, and can be freely
 			if err != nil {
 				return cobraext.FlagParsingError(err, cobraext.ProfileFromFlagName)
 			}
+			profileType, err := cmd.Flags().GetString(cobraext.ProfileTypeFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileTypeFlagName)
+			}
+
 			options := profile.Options{
 				Name:        newProfileName,
 				FromProfile: fromName,
+				Type:        profileType,
 			}
 			err = profile.CreateProfile(options)
 			if err != nil {
@@ -63,6 +80,7 @@ User profiles are not overwritten on upgrade of elastic-stack, and can be freely
 		},
 	}
 	profileNewCommand.Flags().String(cobraext.ProfileFromFlagName, "default", cobraext.ProfileFromFlagDescription)
+	profileNewCommand.Flags().String(cobraext.ProfileTypeFlagName, "", cobraext.ProfileTypeFlagDescription)
 
 	profileDeleteCommand := &cobra.Command{
 		Use:   "delete",
@@ -125,7 +143,156 @@ User profiles are not overwritten on upgrade of elastic-stack, and can be freely
 		},
 	}
 
-	profileCommand.AddCommand(profileNewCommand, profileDeleteCommand, profileListCommand)
+	profileExportCommand := &cobra.Command{
+		Use:   "export [profile] [file.zip]",
+		Short: "Export a profile to a zip archive",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileName, archivePath := args[0], args[1]
+
+			err := profile.ExportProfile(profileName, archivePath)
+			if err != nil {
+				return errors.Wrapf(err, "error exporting profile %s", profileName)
+			}
+
+			fmt.Printf("Exported profile %s to %s\n", profileName, archivePath)
+			return nil
+		},
+	}
+
+	profileImportCommand := &cobra.Command{
+		Use:   "import [file.zip]",
+		Short: "Import a profile from a zip archive",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archivePath := args[0]
+
+			newName, err := cmd.Flags().GetString(cobraext.ProfileNameFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileNameFlagName)
+			}
+
+			profileName, err := profile.ImportProfile(archivePath, newName)
+			if err != nil {
+				return errors.Wrapf(err, "error importing profile from %s", archivePath)
+			}
+
+			fmt.Printf("Imported profile %s from %s\n", profileName, archivePath)
+			return nil
+		},
+	}
+	profileImportCommand.Flags().String(cobraext.ProfileNameFlagName, "", cobraext.ProfileNameFlagDescription)
+
+	profileDiffCommand := &cobra.Command{
+		Use:   "diff [profile] [profile]",
+		Short: "Show differences between two profiles",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			diffs, err := profile.DiffProfiles(args[0], args[1])
+			if err != nil {
+				return errors.Wrap(err, "error comparing profiles")
+			}
+
+			for _, d := range diffs {
+				if d.Diff == "" {
+					fmt.Printf("%s: no differences\n", d.File)
+					continue
+				}
+				fmt.Printf("%s:\n%s\n", d.File, d.Diff)
+			}
+			return nil
+		},
+	}
+
+	profileCheckCommand := &cobra.Command{
+		Use:   "check [profile]",
+		Short: "Check profiles for files diverging from current defaults",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileNames := args
+			if len(profileNames) == 0 {
+				var err error
+				profileNames, err = availableProfilesAsAList()
+				if err != nil {
+					return errors.Wrap(err, "error listing all profiles")
+				}
+			}
+
+			var rows [][]string
+			var details []string
+			for _, profileName := range profileNames {
+				checks, err := profile.CheckProfile(profileName)
+				if err != nil {
+					return errors.Wrapf(err, "error checking profile %s", profileName)
+				}
+
+				for _, c := range checks {
+					rows = append(rows, []string{profileName, c.File, string(c.Status)})
+					// Show details only if a single profile is checked.
+					if len(args) > 0 && (c.Status == profile.FileConflict || c.Status == profile.FileModified) {
+						details = append(details, fmt.Sprintf("%s:\n%s", c.File, c.ThreeWayView()))
+					}
+				}
+			}
+			renderStatusTable(os.Stdout, []string{"Profile", "File", "Status"}, rows)
+			for _, d := range details {
+				fmt.Println(d)
+			}
+			return nil
+		},
+	}
+
+	profileUpgradeCommand := &cobra.Command{
+		Use:   "upgrade [profile]",
+		Short: "Upgrade profile files to current defaults",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileName := args[0]
+
+			overwriteModified, err := cmd.Flags().GetBool(cobraext.ProfileOverwriteModifiedFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileOverwriteModifiedFlagName)
+			}
+
+			checks, err := profile.UpgradeProfile(profileName, overwriteModified)
+			if err != nil {
+				return errors.Wrapf(err, "error upgrading profile %s", profileName)
+			}
+
+			var conflicts []profile.FileCheck
+			for _, c := range checks {
+				switch {
+				case c.Status.Upgradable():
+					fmt.Printf("%s: upgraded (%s)\n", c.File, c.Status)
+				case c.Status == profile.FileModified && overwriteModified:
+					fmt.Printf("%s: overwritten with current default (%s), discarded changes:\n%s\n", c.File, c.Status, c.ThreeWayView())
+				case c.Status == profile.FileConflict || c.Status == profile.FileModified:
+					conflicts = append(conflicts, c)
+				}
+			}
+
+			var modified bool
+			for _, c := range conflicts {
+				fmt.Printf("%s: not upgraded (%s), resolve changes manually:\n%s\n", c.File, c.Status, c.ThreeWayView())
+				modified = modified || c.Status == profile.FileModified
+			}
+			if modified {
+				fmt.Printf("Previous defaults of modified files are unknown. If the changes above aren't needed, overwrite these files with current defaults using --%s.\n",
+					cobraext.ProfileOverwriteModifiedFlagName)
+			}
+			if len(conflicts) > 0 {
+				return fmt.Errorf("profile %s upgraded partially, %d file(s) need to be updated manually", profileName, len(conflicts))
+			}
+
+			fmt.Printf("Profile %s is up-to-date.\n", profileName)
+			return nil
+		},
+	}
+
+	profileUpgradeCommand.Flags().Bool(cobraext.ProfileOverwriteModifiedFlagName, false, cobraext.ProfileOverwriteModifiedFlagDescription)
+
+	profileCommand.AddCommand(profileNewCommand, profileDeleteCommand, profileListCommand,
+		profileExportCommand, profileImportCommand, profileDiffCommand, profileCheckCommand, profileUpgradeCommand)
 
 	return cobraext.NewCommand(profileCommand, cobraext.ContextGlobal)
 
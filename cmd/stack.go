@@ -57,11 +57,6 @@ func setupStackCommand() *cobraext.Command {
 
 			common.TrimStringSlice(services)
 
-			err = validateServicesFlag(services)
-			if err != nil {
-				return errors.Wrap(err, "validating services failed")
-			}
-
 			stackVersion, err := cmd.Flags().GetString(cobraext.StackVersionFlagName)
 			if err != nil {
 				return cobraext.FlagParsingError(err, cobraext.StackVersionFlagName)
@@ -84,6 +79,17 @@ func setupStackCommand() *cobraext.Command {
 				return errors.Wrap(err, "error loading profile")
 			}
 			cmd.Printf("Using profile %s.\n", usrProfile.ProfilePath)
+
+			sidecars, err := stack.SidecarServices(usrProfile)
+			if err != nil {
+				return errors.Wrap(err, "reading side-car services failed")
+			}
+
+			err = validateServicesFlag(services, sidecars)
+			if err != nil {
+				return errors.Wrap(err, "validating services failed")
+			}
+
 			cmd.Println(`Remember to load stack environment variables using 'eval "$(elastic-package stack shellinit)"'.`)
 
 			err = stack.BootUp(stack.Options{
@@ -218,9 +224,27 @@ func setupStackCommand() *cobraext.Command {
 				return errors.Wrap(err, "error loading profile")
 			}
 
+			sectionNames, err := cmd.Flags().GetStringSlice(cobraext.StackDumpSectionsFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackDumpSectionsFlagName)
+			}
+			common.TrimStringSlice(sectionNames)
+
+			sections, err := validateDumpSectionsFlag(sectionNames)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackDumpSectionsFlagName)
+			}
+
+			archive, err := cmd.Flags().GetBool(cobraext.StackDumpArchiveFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackDumpArchiveFlagName)
+			}
+
 			target, err := stack.Dump(stack.DumpOptions{
-				Output:  output,
-				Profile: profile,
+				Output:   output,
+				Profile:  profile,
+				Sections: sections,
+				Archive:  archive,
 			})
 			if err != nil {
 				return errors.Wrap(err, "dump failed")
@@ -233,6 +257,9 @@ func setupStackCommand() *cobraext.Command {
 		},
 	}
 	dumpCommand.Flags().StringP(cobraext.StackDumpOutputFlagName, "", "elastic-stack-dump", cobraext.StackDumpOutputFlagDescription)
+	dumpCommand.Flags().StringSliceP(cobraext.StackDumpSectionsFlagName, "", nil,
+		fmt.Sprintf(cobraext.StackDumpSectionsFlagDescription, strings.Join(availableDumpSectionsAsList(), ",")))
+	dumpCommand.Flags().BoolP(cobraext.StackDumpArchiveFlagName, "", false, cobraext.StackDumpArchiveFlagDescription)
 
 	cmd := &cobra.Command{
 		Use:   "stack",
@@ -245,7 +272,8 @@ func setupStackCommand() *cobraext.Command {
 		downCommand,
 		updateCommand,
 		shellInitCommand,
-		dumpCommand)
+		dumpCommand,
+		setupStackStatusCommand())
 
 	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
 }
@@ -260,11 +288,20 @@ func availableServicesAsList() []string {
 	return available
 }
 
-func validateServicesFlag(services []string) error {
-	selected := map[string]struct{}{}
+// validateServicesFlag checks if selected services are available in the stack, including side-car services
+// defined in the profile.
+func validateServicesFlag(services []string, sidecars []string) error {
+	available := map[string]struct{}{}
+	for aService := range availableServices {
+		available[aService] = struct{}{}
+	}
+	for _, aService := range sidecars {
+		available[aService] = struct{}{}
+	}
 
+	selected := map[string]struct{}{}
 	for _, aService := range services {
-		if _, found := availableServices[aService]; !found {
+		if _, found := available[aService]; !found {
 			return fmt.Errorf("service \"%s\" is not available", aService)
 		}
 
@@ -276,3 +313,27 @@ func validateServicesFlag(services []string) error {
 	}
 	return nil
 }
+
+func availableDumpSectionsAsList() []string {
+	var available []string
+	for _, section := range stack.DumpSections() {
+		available = append(available, string(section))
+	}
+	return available
+}
+
+func validateDumpSectionsFlag(sectionNames []string) ([]stack.DumpSection, error) {
+	available := map[string]struct{}{}
+	for _, section := range availableDumpSectionsAsList() {
+		available[section] = struct{}{}
+	}
+
+	var sections []stack.DumpSection
+	for _, name := range sectionNames {
+		if _, found := available[name]; !found {
+			return nil, fmt.Errorf("dump section \"%s\" is not available", name)
+		}
+		sections = append(sections, stack.DumpSection(name))
+	}
+	return sections, nil
+}
@@ -0,0 +1,265 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const stackStatusLongDescription = `Use this command to check the health of the Elastic stack.
+
+The command lists containers of stack services (only the Elastic Agent for remote profiles), checks the health of Elasticsearch, Kibana, Fleet and the Package Registry, and lists enrolled Elastic Agents. It exits with a non-zero code if any of the components is not healthy.`
+
+// componentStatus describes the health of a single stack component, as reported by its API.
+type componentStatus struct {
+	name    string
+	healthy bool
+	details string
+}
+
+func setupStackStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show status of the stack",
+		Long:  stackStatusLongDescription,
+		RunE:  stackStatusCommandAction,
+	}
+}
+
+func stackStatusCommandAction(cmd *cobra.Command, args []string) error {
+	profileName, err := cmd.Flags().GetString(cobraext.ProfileFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.ProfileFlagName)
+	}
+
+	usrProfile, err := profile.LoadProfile(profileName)
+	if err != nil {
+		return errors.Wrap(err, "error loading profile")
+	}
+
+	services, err := stack.Status(stack.Options{Profile: usrProfile})
+	if err != nil {
+		return errors.Wrap(err, "can't read status of stack services")
+	}
+
+	healthy := true
+	for _, s := range services {
+		if !s.Healthy() {
+			healthy = false
+		}
+	}
+	printStackServices(os.Stdout, services)
+
+	err = setStackEnvironment(usrProfile)
+	if err != nil {
+		return errors.Wrap(err, "can't set stack environment variables")
+	}
+
+	profileConfig, err := usrProfile.Config()
+	if err != nil {
+		return errors.Wrap(err, "can't read profile configuration")
+	}
+
+	components := []componentStatus{
+		elasticsearchStatus(),
+		kibanaStatus(),
+		fleetStatus(),
+	}
+	// Remote stacks use their own package registry.
+	if !profileConfig.IsRemote() {
+		components = append(components, packageRegistryStatus(usrProfile))
+	}
+	for _, c := range components {
+		if !c.healthy {
+			healthy = false
+		}
+	}
+	printStackComponents(os.Stdout, components)
+
+	agents, err := listAgents()
+	if err != nil {
+		healthy = false
+		color.New(color.FgRed).Fprintf(os.Stdout, "Can't list enrolled agents: %v\n", err)
+	} else {
+		printAgents(os.Stdout, agents)
+	}
+
+	if !healthy {
+		return errors.New("Elastic stack is not healthy")
+	}
+	cmd.Println("Elastic stack is healthy")
+	return nil
+}
+
+// setStackEnvironment sets environment variables used by Elasticsearch and Kibana clients,
+// unless they are already set (e.g. using "elastic-package stack shellinit").
+func setStackEnvironment(usrProfile *profile.Profile) error {
+	env, err := stack.Environment(usrProfile)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range env {
+		if _, found := os.LookupEnv(k); found {
+			continue
+		}
+		err = os.Setenv(k, v)
+		if err != nil {
+			return errors.Wrapf(err, "can't set environment variable %s", k)
+		}
+	}
+	return nil
+}
+
+func elasticsearchStatus() componentStatus {
+	status := componentStatus{name: "Elasticsearch"}
+
+	client, err := elasticsearch.Client()
+	if err != nil {
+		status.details = err.Error()
+		return status
+	}
+
+	health, err := elasticsearch.CheckClusterHealth(client)
+	if err != nil {
+		status.details = err.Error()
+		return status
+	}
+
+	status.healthy = health.Healthy()
+	status.details = fmt.Sprintf("cluster %s: %s (nodes: %d)", health.ClusterName, health.Status, health.NumberOfNodes)
+	return status
+}
+
+func kibanaStatus() componentStatus {
+	status := componentStatus{name: "Kibana"}
+
+	client, err := kibana.NewClient()
+	if err != nil {
+		status.details = err.Error()
+		return status
+	}
+
+	kibanaStatus, err := client.Status()
+	if err != nil {
+		status.details = err.Error()
+		return status
+	}
+
+	status.healthy = kibanaStatus.Healthy()
+	status.details = fmt.Sprintf("version %s: %s", kibanaStatus.Version, kibanaStatus.Overall)
+	return status
+}
+
+func fleetStatus() componentStatus {
+	status := componentStatus{name: "Fleet Server"}
+
+	client, err := kibana.NewClient()
+	if err != nil {
+		status.details = err.Error()
+		return status
+	}
+
+	setupStatus, err := client.FleetSetupStatus()
+	if err != nil {
+		status.details = err.Error()
+		return status
+	}
+
+	status.healthy = setupStatus.IsReady
+	if setupStatus.IsReady {
+		status.details = "ready"
+	} else {
+		status.details = fmt.Sprintf("not ready, missing requirements: %s", strings.Join(setupStatus.MissingRequirements, ", "))
+	}
+	return status
+}
+
+func packageRegistryStatus(usrProfile *profile.Profile) componentStatus {
+	status := componentStatus{name: "Package Registry"}
+
+	err := stack.PackageRegistryHealth(usrProfile)
+	if err != nil {
+		status.details = err.Error()
+		return status
+	}
+
+	status.healthy = true
+	status.details = "healthy"
+	return status
+}
+
+func listAgents() ([]kibana.Agent, error) {
+	client, err := kibana.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.ListAgents()
+}
+
+func printStackServices(w io.Writer, services []stack.ServiceStatus) {
+	color.New(color.Bold).Fprintln(w, "Services:")
+
+	var rows [][]string
+	for _, s := range services {
+		health := s.Health
+		if health == "" {
+			health = "-"
+		}
+		rows = append(rows, []string{s.Name, s.State, health, s.Image, s.Version})
+	}
+	renderStatusTable(w, []string{"Service", "State", "Health", "Image", "Version"}, rows)
+}
+
+func printStackComponents(w io.Writer, components []componentStatus) {
+	color.New(color.Bold).Fprintln(w, "Components:")
+
+	var rows [][]string
+	for _, c := range components {
+		health := "healthy"
+		if !c.healthy {
+			health = "unhealthy"
+		}
+		rows = append(rows, []string{c.name, health, c.details})
+	}
+	renderStatusTable(w, []string{"Component", "Health", "Details"}, rows)
+}
+
+func printAgents(w io.Writer, agents []kibana.Agent) {
+	color.New(color.Bold).Fprintln(w, "Enrolled agents:")
+
+	var rows [][]string
+	for _, a := range agents {
+		rows = append(rows, []string{a.ID, a.LocalMetadata.Host.Name, a.LocalMetadata.Elastic.Agent.Version, a.Status, a.PolicyID})
+	}
+	renderStatusTable(w, []string{"ID", "Host name", "Version", "Status", "Policy ID"}, rows)
+}
+
+func renderStatusTable(w io.Writer, header []string, rows [][]string) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+
+	headerColors := make([]tablewriter.Colors, len(header))
+	for i := range headerColors {
+		headerColors[i] = twColor(tablewriter.Colors{tablewriter.Bold})
+	}
+	table.SetHeaderColor(headerColors...)
+	table.AppendBulk(rows)
+	table.Render()
+}
@@ -13,20 +13,26 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/elastic/elastic-package/internal/builder"
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/common"
 	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/installer"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
 	"github.com/elastic/elastic-package/internal/testrunner"
 	"github.com/elastic/elastic-package/internal/testrunner/reporters/formats"
 	"github.com/elastic/elastic-package/internal/testrunner/reporters/outputs"
 	_ "github.com/elastic/elastic-package/internal/testrunner/runners" // register all test runners
+	"github.com/elastic/elastic-package/internal/testrunner/runners/system"
 )
 
 const testLongDescription = `Use this command to run tests on a package. Currently, the following types of tests are available:
 
 #### Asset Loading Tests
-These tests ensure that all the Elasticsearch and Kibana assets defined by your package get loaded up as expected.
+These tests ensure that all the Elasticsearch and Kibana assets defined by your package get loaded up as expected. Optionally, dashboards are also exported back from Kibana and compared with ones stored in the package.
 
 For details on how to run asset loading tests for a package, see the [HOWTO guide](https://github.com/elastic/elastic-package/blob/master/docs/howto/asset_testing.md).
 
@@ -35,6 +41,11 @@ These tests allow you to exercise any Ingest Node Pipelines defined by your pack
 
 For details on how to configure pipeline test for a package, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/master/docs/howto/pipeline_testing.md).
 
+#### Policy Tests
+These tests allow you to verify Elastic Agent stream templates of your packages, without running the Elastic stack.
+
+For details on how to configure policy tests for a package, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/master/docs/howto/policy_testing.md).
+
 #### Static Tests
 These tests allow you to verify if all static resources of the package are valid, e.g. if all fields of the sample_event.json are documented.
 
@@ -67,6 +78,8 @@ func setupTestCommand() *cobraext.Command {
 	cmd.PersistentFlags().StringP(cobraext.ReportFormatFlagName, "", string(formats.ReportFormatHuman), cobraext.ReportFormatFlagDescription)
 	cmd.PersistentFlags().StringP(cobraext.ReportOutputFlagName, "", string(outputs.ReportOutputSTDOUT), cobraext.ReportOutputFlagDescription)
 	cmd.PersistentFlags().DurationP(cobraext.DeferCleanupFlagName, "", 0, cobraext.DeferCleanupFlagDescription)
+	cmd.PersistentFlags().BoolP(cobraext.AlwaysCollectDiagnosticsFlagName, "", false, cobraext.AlwaysCollectDiagnosticsFlagDescription)
+	cmd.PersistentFlags().BoolP(cobraext.KeepTerraformStateFlagName, "", false, cobraext.KeepTerraformStateFlagDescription)
 
 	for testType, runner := range testrunner.TestRunners() {
 		action := testTypeCommandActionFactory(runner)
@@ -83,6 +96,15 @@ func setupTestCommand() *cobraext.Command {
 			testTypeCmd.Flags().StringSliceP(cobraext.DataStreamsFlagName, "d", nil, cobraext.DataStreamsFlagDescription)
 		}
 
+		if runner.StackRequired() {
+			testTypeCmd.Flags().StringP(cobraext.ProfileFlagName, "p", lookupEnv(), fmt.Sprintf(cobraext.ProfileFlagDescription, profileNameEnvVar))
+		}
+
+		// Only system tests can be run against multiple stack versions.
+		if testType == system.TestType {
+			testTypeCmd.Flags().StringSliceP(cobraext.StackVersionsFlagName, "", nil, cobraext.StackVersionsFlagDescription)
+		}
+
 		cmd.AddCommand(testTypeCmd)
 	}
 
@@ -173,25 +195,78 @@ func testTypeCommandActionFactory(runner testrunner.TestRunner) cobraext.Command
 			return cobraext.FlagParsingError(err, cobraext.DeferCleanupFlagName)
 		}
 
-		esClient, err := elasticsearch.Client()
+		alwaysCollectDiagnostics, err := cmd.Flags().GetBool(cobraext.AlwaysCollectDiagnosticsFlagName)
 		if err != nil {
-			return errors.Wrap(err, "can't create Elasticsearch client")
+			return cobraext.FlagParsingError(err, cobraext.AlwaysCollectDiagnosticsFlagName)
+		}
+
+		keepTerraformState, err := cmd.Flags().GetBool(cobraext.KeepTerraformStateFlagName)
+		if err != nil {
+			return cobraext.FlagParsingError(err, cobraext.KeepTerraformStateFlagName)
+		}
+
+		options := testrunner.TestOptions{
+			PackageRootPath:    packageRootPath,
+			GenerateTestResult: generateTestResult,
+			DeferCleanup:       deferCleanup,
+
+			AlwaysCollectDiagnostics: alwaysCollectDiagnostics,
+			KeepTerraformState:       keepTerraformState,
+		}
+
+		var stackVersions []string
+		if cmd.Flags().Lookup(cobraext.StackVersionsFlagName) != nil {
+			stackVersions, err = cmd.Flags().GetStringSlice(cobraext.StackVersionsFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackVersionsFlagName)
+			}
+			common.TrimStringSlice(stackVersions)
+		}
+
+		var profileName string
+		if cmd.Flags().Lookup(cobraext.ProfileFlagName) != nil {
+			profileName, err = cmd.Flags().GetString(cobraext.ProfileFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileFlagName)
+			}
+
+			options.Profile, err = profile.LoadProfile(profileName)
+			if err != nil {
+				return errors.Wrap(err, "error loading profile")
+			}
 		}
 
 		var results []testrunner.TestResult
-		for _, folder := range testFolders {
-			r, err := testrunner.Run(testType, testrunner.TestOptions{
-				TestFolder:         folder,
-				PackageRootPath:    packageRootPath,
-				GenerateTestResult: generateTestResult,
-				ESClient:           esClient,
-				DeferCleanup:       deferCleanup,
-			})
+		if len(stackVersions) > 0 {
+			usrProfile := options.Profile
+			profileConfig, err := usrProfile.Config()
+			if err != nil {
+				return errors.Wrap(err, "can't read profile configuration")
+			}
+			if profileConfig.IsRemote() {
+				return fmt.Errorf("stack versions can't be selected for the remote profile %s", profileName)
+			}
 
-			results = append(results, r...)
+			err = ensureStackNotRunning(usrProfile)
+			if err != nil {
+				return err
+			}
+
+			results, err = runTestsAgainstStackVersions(cmd, testType, stackVersions, usrProfile, testFolders, options)
+			if err != nil {
+				return err
+			}
+		} else {
+			if runner.StackRequired() {
+				options.ESClient, err = elasticsearch.Client()
+				if err != nil {
+					return errors.Wrap(err, "can't create Elasticsearch client")
+				}
+			}
 
+			results, err = runTests(testType, testFolders, options)
 			if err != nil {
-				return errors.Wrapf(err, "error running package %s tests", testType)
+				return err
 			}
 		}
 
@@ -220,6 +295,128 @@ func testTypeCommandActionFactory(runner testrunner.TestRunner) cobraext.Command
 	}
 }
 
+func runTests(testType testrunner.TestType, testFolders []testrunner.TestFolder, options testrunner.TestOptions) ([]testrunner.TestResult, error) {
+	var results []testrunner.TestResult
+	for _, folder := range testFolders {
+		options.TestFolder = folder
+		r, err := testrunner.Run(testType, options)
+
+		results = append(results, r...)
+
+		if err != nil {
+			return results, errors.Wrapf(err, "error running package %s tests", testType)
+		}
+	}
+	return results, nil
+}
+
+// runTestsAgainstStackVersions runs tests against the Elastic stack booted up in each of the stack versions.
+// The package is built once and installed in every stack. If the stack can't be prepared, an error
+// is reported as the test result for this stack version and remaining versions are still tested.
+func runTestsAgainstStackVersions(cmd *cobra.Command, testType testrunner.TestType, stackVersions []string,
+	usrProfile *profile.Profile, testFolders []testrunner.TestFolder, options testrunner.TestOptions) ([]testrunner.TestResult, error) {
+	m, err := packages.ReadPackageManifestFromPackageRoot(options.PackageRootPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading package manifest failed (path: %s)", options.PackageRootPath)
+	}
+
+	cmd.Println("Build the package")
+	target, err := builder.BuildPackage()
+	if err != nil {
+		return nil, errors.Wrap(err, "building package failed")
+	}
+	cmd.Printf("Package built: %s\n", target)
+
+	var results []testrunner.TestResult
+	for _, stackVersion := range stackVersions {
+		cmd.Printf("Run %s tests against the Elastic stack %s\n", testType, stackVersion)
+		r, err := runTestsAgainstStackVersion(testType, stackVersion, usrProfile, *m, testFolders, options)
+		if err != nil {
+			r = append(r, testrunner.TestResult{
+				Package:      m.Name,
+				TestType:     testType,
+				StackVersion: stackVersion,
+				ErrorMsg:     err.Error(),
+			})
+		}
+
+		for i := range r {
+			r[i].StackVersion = stackVersion
+		}
+		results = append(results, r...)
+	}
+	return results, nil
+}
+
+func runTestsAgainstStackVersion(testType testrunner.TestType, stackVersion string, usrProfile *profile.Profile,
+	m packages.PackageManifest, testFolders []testrunner.TestFolder, options testrunner.TestOptions) ([]testrunner.TestResult, error) {
+	stackOptions := stack.Options{
+		DaemonMode:   true,
+		StackVersion: stackVersion,
+		Profile:      usrProfile,
+	}
+
+	err := stack.BootUp(stackOptions)
+	if err != nil {
+		// The stack wasn't running before, so only containers created by the failed boot are removed.
+		tearDownStack(stackOptions)
+		return nil, errors.Wrapf(err, "booting up the Elastic stack %s failed", stackVersion)
+	}
+	defer tearDownStack(stackOptions)
+
+	env, err := stack.Environment(usrProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read stack environment variables")
+	}
+	for k, v := range env {
+		err = os.Setenv(k, v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't set environment variable %s", k)
+		}
+	}
+
+	options.ESClient, err = elasticsearch.Client()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't create Elasticsearch client")
+	}
+	options.StackVersion = stackVersion
+
+	packageInstaller, err := installer.CreateForManifest(m, usrProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't create the package installer")
+	}
+
+	_, err = packageInstaller.Install()
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't install the package in the Elastic stack %s", stackVersion)
+	}
+
+	return runTests(testType, testFolders, options)
+}
+
+// ensureStackNotRunning verifies that the Elastic stack isn't running, as testing against multiple stack versions
+// boots up and takes down the stack for every version.
+func ensureStackNotRunning(usrProfile *profile.Profile) error {
+	statuses, err := stack.Status(stack.Options{Profile: usrProfile})
+	if err != nil {
+		return errors.Wrap(err, "can't read status of the stack")
+	}
+	for _, status := range statuses {
+		if status.State != stack.ServiceStateNotCreated {
+			return fmt.Errorf("the Elastic stack is already running (service: %s, state: %s), take it down with \"elastic-package stack down\" before testing against multiple stack versions",
+				status.Name, status.State)
+		}
+	}
+	return nil
+}
+
+func tearDownStack(options stack.Options) {
+	err := stack.TearDown(options)
+	if err != nil {
+		logger.Errorf("can't take down the Elastic stack %s: %v", options.StackVersion, err)
+	}
+}
+
 func validateDataStreamsFlag(packageRootPath string, dataStreams []string) error {
 	for _, dataStream := range dataStreams {
 		path := filepath.Join(packageRootPath, "data_stream", dataStream)
@@ -42,7 +42,8 @@ func uninstallCommandAction(cmd *cobra.Command, args []string) error {
 		return errors.Wrapf(err, "reading package manifest failed (path: %s)", packageRootPath)
 	}
 
-	packageInstaller, err := installer.CreateForManifest(*m)
+	// Packages are only removed, so the profile isn't needed.
+	packageInstaller, err := installer.CreateForManifest(*m, nil)
 	if err != nil {
 		return errors.Wrap(err, "can't create the package installer")
 	}
@@ -0,0 +1,58 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/kind"
+	"github.com/elastic/elastic-package/internal/kubectl"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// staleTestNamespaceAge is the age of test namespaces, after which they're considered as left over by
+// interrupted test runs. Younger namespaces may belong to test runs still in progress, e.g. in another terminal.
+const staleTestNamespaceAge = time.Hour
+
+// KubernetesNamespaces function removes namespaces left over by interrupted system test runs
+// from the kind cluster. It's skipped if the kind context isn't selected.
+func KubernetesNamespaces() ([]string, error) {
+	logger.Debug("Clean test namespaces in the Kubernetes cluster")
+
+	err := kind.VerifyContext()
+	if err != nil {
+		logger.Debugf("Kubernetes cluster is not available, skipping: %v", err)
+		return nil, nil
+	}
+
+	namespaces, err := kubectl.FindNamespaces(kubectl.TestRunLabel)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't find test namespaces")
+	}
+
+	var removed []string
+	for _, name := range staleNamespaces(namespaces, time.Now()) {
+		err = kubectl.DeleteNamespace(name)
+		if err != nil {
+			return removed, errors.Wrapf(err, "can't delete test namespace (name: %s)", name)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+func staleNamespaces(namespaces []kubectl.Namespace, now time.Time) []string {
+	var stale []string
+	for _, ns := range namespaces {
+		if now.Sub(ns.CreatedAt) < staleTestNamespaceAge {
+			logger.Debugf("skip test namespace %s, its test run may be still in progress", ns.Name)
+			continue
+		}
+		stale = append(stale, ns.Name)
+	}
+	return stale
+}
@@ -0,0 +1,25 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/kubectl"
+)
+
+func TestStaleNamespaces(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	namespaces := []kubectl.Namespace{
+		{Name: "elastic-package-test-1", CreatedAt: now.Add(-3 * time.Hour)},
+		{Name: "elastic-package-test-2", CreatedAt: now.Add(-10 * time.Minute)},
+		{Name: "elastic-package-test-3", CreatedAt: now.Add(-staleTestNamespaceAge)},
+	}
+
+	require.Equal(t, []string{"elastic-package-test-1", "elastic-package-test-3"}, staleNamespaces(namespaces, now))
+}
@@ -0,0 +1,43 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// TerraformWorkspaces function returns paths of Terraform workspaces kept by system test runs (--keep-terraform-state).
+// Workspaces aren't removed, as infrastructure described by their state hasn't been destroyed.
+func TerraformWorkspaces() ([]string, error) {
+	logger.Debug("Find kept Terraform workspaces")
+
+	locationManager, err := locations.NewLocationManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't find Terraform state dir")
+	}
+
+	fileInfos, err := ioutil.ReadDir(locationManager.TerraformStateDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read directory (path: %s)", locationManager.TerraformStateDir())
+	}
+
+	var workspaces []string
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() {
+			workspaces = append(workspaces, filepath.Join(locationManager.TerraformStateDir(), fileInfo.Name()))
+		}
+	}
+	return workspaces, nil
+}
@@ -6,6 +6,9 @@ package cobraext
 
 // Flag names and descriptions used by CLI commands.
 const (
+	AlwaysCollectDiagnosticsFlagName        = "always-collect-diagnostics"
+	AlwaysCollectDiagnosticsFlagDescription = "collect diagnostics of system tests also for passing test cases"
+
 	CheckConditionFlagName        = "check-condition"
 	CheckConditionFlagDescription = "check if the condition is met for the package, but don't install the package (e.g. kibana.version=7.10.0)"
 
@@ -21,6 +24,9 @@ const (
 	DataStreamsFlagName        = "data-streams"
 	DataStreamsFlagDescription = "comma-separated data streams to test"
 
+	DeployerFlagName        = "deployer"
+	DeployerFlagDescription = "name of the service deployer, if multiple ones are defined (e.g. docker, k8s, tf)"
+
 	DirectionFlagName        = "direction"
 	DirectionFlagDescription = "promotion direction"
 
@@ -39,6 +45,9 @@ const (
 	GenerateTestResultFlagName        = "generate"
 	GenerateTestResultFlagDescription = "generate test result file"
 
+	KeepTerraformStateFlagName        = "keep-terraform-state"
+	KeepTerraformStateFlagDescription = "keep Terraform workspaces (definitions and state) of system tests for debugging purposes"
+
 	ProfileFlagName        = "profile"
 	ProfileFlagDescription = "select a profile to use for the stack configuration. Can also be set with %s"
 
@@ -48,6 +57,15 @@ const (
 	ProfileFromFlagName        = "from"
 	ProfileFromFlagDescription = "copy profile from the specified existing profile"
 
+	ProfileTypeFlagName        = "type"
+	ProfileTypeFlagDescription = "type of the profile: local (stack booted up locally) or remote (existing deployment)"
+
+	ProfileNameFlagName        = "name"
+	ProfileNameFlagDescription = "name of the imported profile (defaults to the name stored in the archive)"
+
+	ProfileOverwriteModifiedFlagName        = "overwrite-modified"
+	ProfileOverwriteModifiedFlagDescription = "overwrite modified files, whose previous defaults are unknown, with current defaults"
+
 	NewestOnlyFlagName        = "newest-only"
 	NewestOnlyFlagDescription = "promote newest packages and remove old ones"
 
@@ -60,6 +78,12 @@ const (
 	ReportOutputFlagName        = "report-output"
 	ReportOutputFlagDescription = "output location for test report"
 
+	SavedObjectIDsFlagName        = "id"
+	SavedObjectIDsFlagDescription = "Kibana saved object IDs (comma-separated values)"
+
+	SavedObjectTypeFlagName        = "type"
+	SavedObjectTypeFlagDescription = "type of exported saved objects (%s)"
+
 	ShowAllFlagName        = "all"
 	ShowAllFlagDescription = "show all deployed package revisions"
 
@@ -67,14 +91,32 @@ const (
 	SkipPullRequestFlagDescription = "skip opening a new pull request"
 
 	StackServicesFlagName        = "services"
-	StackServicesFlagDescription = "component services (comma-separated values: \"%s\")"
+	StackServicesFlagDescription = "component services (comma-separated values: \"%s\", or side-car services defined in the profile)"
+
+	StackVersionsFlagName        = "stack-versions"
+	StackVersionsFlagDescription = "run tests against the Elastic stack booted up in each of the versions (comma-separated values: 7.14.0,7.15.0)"
 
 	StackVersionFlagName        = "version"
 	StackVersionFlagDescription = "stack version"
 
+	StackDumpArchiveFlagName        = "archive"
+	StackDumpArchiveFlagDescription = "pack the stack dump into a single .tar.gz archive"
+
 	StackDumpOutputFlagName        = "output"
 	StackDumpOutputFlagDescription = "output location for the stack dump"
 
+	StackDumpSectionsFlagName        = "sections"
+	StackDumpSectionsFlagDescription = "sections of the stack dump (comma-separated values: \"%s\")"
+
+	TargetVersionFlagName        = "target-version"
+	TargetVersionFlagDescription = "export from the Elastic stack in this version, e.g. the minimum Kibana version supported by the package"
+
+	WithIndexPatternsFlagName        = "with-index-patterns"
+	WithIndexPatternsFlagDescription = "export also referenced index patterns (for packages shipping custom index patterns)"
+
+	WithReferencesFlagName        = "with-references"
+	WithReferencesFlagDescription = "export also all objects referenced by selected ones, directly or indirectly"
+
 	VerboseFlagName        = "verbose"
 	VerboseFlagDescription = "verbose mode"
 )
@@ -12,3 +12,13 @@ func TrimStringSlice(slice []string) {
 		slice[iterator] = strings.TrimSpace(item)
 	}
 }
+
+// StringSliceContains checks if the slice contains the given string.
+func StringSliceContains(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
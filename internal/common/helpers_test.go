@@ -17,3 +17,11 @@ func TestTrimStringSlice(t *testing.T) {
 	TrimStringSlice(strs)
 	require.Equal(t, expected, strs)
 }
+
+func TestStringSliceContains(t *testing.T) {
+	strs := []string{"foo", "bar"}
+
+	require.True(t, StringSliceContains(strs, "bar"))
+	require.False(t, StringSliceContains(strs, "baz"))
+	require.False(t, StringSliceContains(nil, "foo"))
+}
@@ -247,6 +247,20 @@ func (p *Project) Logs(opts CommandOptions) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// ContainerIDs returns IDs of containers in the Docker Compose project, including stopped ones.
+func (p *Project) ContainerIDs(opts CommandOptions) ([]string, error) {
+	args := p.baseArgs()
+	args = append(args, "ps", "-a", "-q")
+	args = append(args, opts.ExtraArgs...)
+	args = append(args, opts.Services...)
+
+	var b bytes.Buffer
+	if err := p.runDockerComposeCmd(dockerComposeOptions{args: args, env: opts.Env, stdout: &b}); err != nil {
+		return nil, errors.Wrap(err, "running Docker Compose ps command failed")
+	}
+	return strings.Fields(b.String()), nil
+}
+
 func (p *Project) baseArgs() []string {
 	var args []string
 	for _, path := range p.composeFilePaths {
@@ -27,6 +27,7 @@ const (
 
 var (
 	serviceLogsDir        = filepath.Join(temporaryDir, "service_logs")
+	terraformStateDir     = filepath.Join(temporaryDir, "terraform_state")
 	kubernetesDeployerDir = filepath.Join(deployerDir, "kubernetes")
 	terraformDeployerDir  = filepath.Join(deployerDir, "terraform")
 )
@@ -102,6 +103,11 @@ func (loc LocationManager) ServiceLogDir() string {
 	return filepath.Join(loc.stackPath, serviceLogsDir)
 }
 
+// TerraformStateDir returns the directory with Terraform workspaces (definitions and state) of test runs
+func (loc LocationManager) TerraformStateDir() string {
+	return filepath.Join(loc.stackPath, terraformStateDir)
+}
+
 // configurationDir returns the configuration directory location
 func configurationDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
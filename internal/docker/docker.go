@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -19,7 +20,36 @@ import (
 // NetworkDescription describes the Docker network and connected Docker containers.
 type NetworkDescription struct {
 	Containers map[string]struct {
-		Name string
+		Name        string
+		IPv4Address string
+	}
+	IPAM struct {
+		Config []struct {
+			Subnet  string
+			Gateway string
+		}
+	}
+}
+
+// ContainerDescription describes the Docker container.
+type ContainerDescription struct {
+	ID     string
+	Name   string
+	Config struct {
+		Image  string
+		Labels map[string]string
+	}
+	State struct {
+		Status   string
+		ExitCode int
+		Health   *struct {
+			Status string
+			Log    []struct {
+				Start    time.Time
+				ExitCode int
+				Output   string
+			}
+		}
 	}
 }
 
@@ -58,6 +88,39 @@ func ContainerID(containerName string) (string, error) {
 	return string(containerIDs[0]), nil
 }
 
+// ContainerLogs function returns logs (stdout and stderr) of the given container.
+func ContainerLogs(containerName string) ([]byte, error) {
+	cmd := exec.Command("docker", "logs", containerName)
+
+	logger.Debugf("output command: %s", cmd)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read logs of \"%s\" container (output=%q)", containerName, string(output))
+	}
+	return output, nil
+}
+
+// InspectContainers function returns descriptions of the given containers.
+func InspectContainers(containerIDs ...string) ([]ContainerDescription, error) {
+	args := append([]string{"inspect"}, containerIDs...)
+	cmd := exec.Command("docker", args...)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("output command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not inspect containers (stderr=%q)", errOutput.String())
+	}
+
+	var containerDescriptions []ContainerDescription
+	err = json.Unmarshal(output, &containerDescriptions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't unmarshal container inspect (stderr=%q)", errOutput.String())
+	}
+	return containerDescriptions, nil
+}
+
 // InspectNetwork function returns the network description for the selected network.
 func InspectNetwork(network string) ([]NetworkDescription, error) {
 	cmd := exec.Command("docker", "network", "inspect", network)
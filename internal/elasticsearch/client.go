@@ -5,6 +5,7 @@
 package elasticsearch
 
 import (
+	"io/ioutil"
 	"os"
 
 	"github.com/pkg/errors"
@@ -24,11 +25,23 @@ func Client() (*elasticsearch.Client, error) {
 	username := os.Getenv(stack.ElasticsearchUsernameEnv)
 	password := os.Getenv(stack.ElasticsearchPasswordEnv)
 
-	client, err := elasticsearch.NewClient(elasticsearch.Config{
+	config := elasticsearch.Config{
 		Addresses: []string{host},
 		Username:  username,
 		Password:  password,
-	})
+		APIKey:    os.Getenv(stack.ElasticsearchAPIKeyEnv),
+	}
+
+	caCertPath := os.Getenv(stack.CACertificateEnv)
+	if caCertPath != "" {
+		caCert, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't read CA certificate (path: %s)", caCertPath)
+		}
+		config.CACert = caCert
+	}
+
+	client, err := elasticsearch.NewClient(config)
 	if err != nil {
 		return nil, errors.Wrap(err, "can't create instance")
 	}
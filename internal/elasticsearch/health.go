@@ -0,0 +1,52 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package elasticsearch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/go-elasticsearch/v7"
+)
+
+// ClusterHealth describes the health of the Elasticsearch cluster.
+type ClusterHealth struct {
+	ClusterName   string `json:"cluster_name"`
+	Status        string `json:"status"`
+	NumberOfNodes int    `json:"number_of_nodes"`
+}
+
+// Healthy method returns true if the cluster status is green or yellow. Yellow status is expected
+// for single node clusters, as replica shards can't be allocated.
+func (h ClusterHealth) Healthy() bool {
+	return h.Status == "green" || h.Status == "yellow"
+}
+
+// CheckClusterHealth function returns the health of the Elasticsearch cluster.
+func CheckClusterHealth(client *elasticsearch.Client) (*ClusterHealth, error) {
+	resp, err := client.Cluster.Health()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get cluster health")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read cluster health response")
+	}
+
+	if resp.IsError() {
+		return nil, errors.Wrap(NewError(body), "could not get cluster health")
+	}
+
+	var health ClusterHealth
+	err = json.Unmarshal(body, &health)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal cluster health")
+	}
+	return &health, nil
+}
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -21,15 +22,9 @@ import (
 // Dashboards method exports selected dashboards with references objects. All Kibana objects are saved to local files
 // in appropriate directories.
 func Dashboards(kibanaClient *kibana.Client, dashboardsIDs []string) error {
-	packageRoot, err := packages.MustFindPackageRoot()
-	if err != nil {
-		return errors.Wrap(err, "locating package root failed")
-	}
-	logger.Debugf("Package root found: %s", packageRoot)
-
-	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	packageRoot, m, err := findPackage()
 	if err != nil {
-		return errors.Wrapf(err, "reading package manifest failed (path: %s)", packageRoot)
+		return err
 	}
 
 	objects, err := kibanaClient.Export(dashboardsIDs)
@@ -40,12 +35,31 @@ func Dashboards(kibanaClient *kibana.Client, dashboardsIDs []string) error {
 	ctx := &transformationContext{
 		packageName: m.Name,
 	}
+	return transformAndSaveObjects(ctx, packageRoot, *m, objects)
+}
+
+func findPackage() (string, *packages.PackageManifest, error) {
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "locating package root failed")
+	}
+	logger.Debugf("Package root found: %s", packageRoot)
+
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "reading package manifest failed (path: %s)", packageRoot)
+	}
+	return packageRoot, m, nil
+}
 
-	objects, err = applyTransformations(ctx, objects)
+func transformAndSaveObjects(ctx *transformationContext, packageRoot string, m packages.PackageManifest, objects []common.MapStr) error {
+	objects, err := applyTransformations(ctx, objects)
 	if err != nil {
 		return errors.Wrap(err, "can't transform Kibana objects")
 	}
 
+	warnMigrationVersions(m, objects)
+
 	err = saveObjectsToFiles(packageRoot, objects)
 	if err != nil {
 		return errors.Wrap(err, "can't save Kibana objects")
@@ -78,14 +92,14 @@ func saveObjectsToFiles(packageRoot string, objects []common.MapStr) error {
 		}
 
 		// Create target directory
-		targetDir := filepath.Join(packageRoot, "kibana", aType.(string))
+		objectPath := ObjectPath(packageRoot, aType.(string), id.(string))
+		targetDir := filepath.Dir(objectPath)
 		err = os.MkdirAll(targetDir, 0755)
 		if err != nil {
 			return errors.Wrapf(err, "creating target directory failed (path: %s)", targetDir)
 		}
 
 		// Save object to file
-		objectPath := filepath.Join(targetDir, id.(string)+".json")
 		err = ioutil.WriteFile(objectPath, b, 0644)
 		if err != nil {
 			return errors.Wrap(err, "writing to file failed")
@@ -93,3 +107,20 @@ func saveObjectsToFiles(packageRoot string, objects []common.MapStr) error {
 	}
 	return nil
 }
+
+// objectTypeDir returns the name of the package directory storing saved objects of the type.
+// Directory names use underscores instead of dashes (e.g. index-pattern is stored in index_pattern).
+func objectTypeDir(objectType string) string {
+	return strings.ReplaceAll(objectType, "-", "_")
+}
+
+// TransformObjects applies transformations of exported objects, which adjust objects to package conventions.
+// Transformations are idempotent, so they can be applied also to objects already stored in the package.
+func TransformObjects(packageName string, objects []common.MapStr) ([]common.MapStr, error) {
+	return applyTransformations(&transformationContext{packageName: packageName}, objects)
+}
+
+// ObjectPath returns the path to the file storing the saved object in the package.
+func ObjectPath(packageRoot, objectType, id string) string {
+	return filepath.Join(packageRoot, "kibana", objectTypeDir(objectType), id+".json")
+}
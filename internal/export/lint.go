@@ -0,0 +1,344 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/multierror"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// fleetIndexPatterns are index patterns installed by Fleet, so they can be referenced by packages.
+var fleetIndexPatterns = []string{"logs-*", "metrics-*", "synthetics-*", "traces-*"}
+
+// pseudoFields are field names used by visualizations, which don't correspond to document fields.
+var pseudoFields = []string{"Records", "___records___"}
+
+var (
+	// metricIDRegexp matches IDs of metrics (e.g. in TSVB), which are referenced by other metrics using the "field" key.
+	metricIDRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+	titlePrefixRegexp = regexp.MustCompile(`^\[[^\]]+\] `)
+	markdownURLRegexp = regexp.MustCompile(`\]\((https?://[^)\s]+)\)`)
+	dashboardLinkID   = regexp.MustCompile(dashboardLinkPrefix + `([^)?/\s]+)`)
+)
+
+type savedObjectFile struct {
+	path   string
+	object common.MapStr
+
+	objectType string
+	id         string
+}
+
+// LintResult contains problems found in saved objects of the package.
+type LintResult struct {
+	// Errors are problems, which need to be fixed.
+	Errors multierror.Error
+
+	// Warnings are potential problems, e.g. fields not defined in the package, as they may be provided
+	// by other packages.
+	Warnings multierror.Error
+}
+
+// LintSavedObjects checks saved objects stored in the kibana directory of the package. It reports references to
+// objects not shipped by the package, hard-coded index patterns, dashboard titles without the "[Package]" prefix,
+// absolute URLs in markdown and non-standardized IDs as errors. Fields not defined in any data stream and objects
+// migrated by Kibana newer than the minimum version required by the package are reported as warnings.
+func LintSavedObjects(packageRoot string) (*LintResult, error) {
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading package manifest failed (path: %s)", packageRoot)
+	}
+
+	files, err := loadSavedObjectFiles(packageRoot)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't load saved objects")
+	}
+	var result LintResult
+	if len(files) == 0 {
+		return &result, nil
+	}
+
+	definitions, err := loadPackageFields(packageRoot)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't load fields of data streams")
+	}
+
+	available := map[string]bool{}
+	for _, f := range files {
+		available[f.objectType+"/"+f.id] = true
+	}
+
+	ctx := &transformationContext{packageName: m.Name}
+
+	kibanaVersion, err := packages.MinimumKibanaVersion(*m)
+	if err != nil {
+		result.Warnings = append(result.Warnings, errors.Wrap(err, "can't check migration versions of saved objects"))
+	}
+
+	for _, f := range files {
+		rel, _ := filepath.Rel(packageRoot, f.path)
+		rel = filepath.ToSlash(rel)
+		for _, problem := range lintSavedObject(ctx, f, available) {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %s", rel, problem))
+		}
+		if len(definitions) > 0 {
+			for _, problem := range lintFields(f.object, definitions) {
+				result.Warnings = append(result.Warnings, fmt.Errorf("%s: %s", rel, problem))
+			}
+		}
+		if kibanaVersion != nil {
+			for _, problem := range checkMigrationVersion(f.object, kibanaVersion) {
+				result.Warnings = append(result.Warnings, fmt.Errorf("%s: %s", rel, problem))
+			}
+		}
+	}
+	return &result, nil
+}
+
+func loadSavedObjectFiles(packageRoot string) ([]savedObjectFile, error) {
+	paths, err := filepath.Glob(filepath.Join(packageRoot, "kibana", "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []savedObjectFile
+	for _, path := range paths {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading saved object failed (path: %s)", path)
+		}
+
+		var object common.MapStr
+		err = json.Unmarshal(body, &object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unmarshalling saved object failed (path: %s)", path)
+		}
+
+		// Directories with non-standard saved objects (e.g. security rules, ML modules) don't follow
+		// the common structure.
+		objectType, _ := object["type"].(string)
+		id, _ := object["id"].(string)
+		if objectType == "" || id == "" {
+			continue
+		}
+
+		object, err = decodeObject(nil, object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding saved object failed (path: %s)", path)
+		}
+
+		files = append(files, savedObjectFile{
+			path:       path,
+			object:     object,
+			objectType: objectType,
+			id:         id,
+		})
+	}
+	return files, nil
+}
+
+// loadPackageFields loads field definitions of all data streams of the package.
+func loadPackageFields(packageRoot string) ([]fields.FieldDefinition, error) {
+	dataStreamPaths, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []fields.FieldDefinition
+	for _, dataStreamPath := range dataStreamPaths {
+		if _, err := os.Stat(filepath.Join(dataStreamPath, "fields")); os.IsNotExist(err) {
+			continue
+		}
+
+		d, err := fields.LoadFieldsForDataStream(dataStreamPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't load fields for data stream (path: %s)", dataStreamPath)
+		}
+		definitions = append(definitions, d...)
+	}
+	return definitions, nil
+}
+
+func lintSavedObject(ctx *transformationContext, f savedObjectFile, available map[string]bool) []string {
+	var problems []string
+
+	if f.objectType != "index-pattern" {
+		if expected := adjustObjectID(ctx, f.id); expected != f.id {
+			problems = append(problems, fmt.Sprintf("ID %q isn't standardized, expected %q (re-export the object or rename it)", f.id, expected))
+		}
+	}
+	if filepath.Base(f.path) != f.id+".json" {
+		problems = append(problems, fmt.Sprintf("file name doesn't match the object ID %q", f.id))
+	}
+	if filepath.Base(filepath.Dir(f.path)) != objectTypeDir(f.objectType) {
+		problems = append(problems, fmt.Sprintf("object of type %q is stored in the wrong directory, expected kibana/%s", f.objectType, objectTypeDir(f.objectType)))
+	}
+
+	if f.objectType == "dashboard" {
+		title, _ := f.object.GetValue("attributes.title")
+		if t, _ := title.(string); !titlePrefixRegexp.MatchString(t) {
+			problems = append(problems, fmt.Sprintf("dashboard title %q doesn't start with the \"[Package] \" prefix", t))
+		}
+	}
+
+	problems = append(problems, lintReferences(f, available)...)
+	problems = append(problems, lintMarkdown(f.object, available)...)
+	return problems
+}
+
+// lintReferences checks if referenced objects are shipped by the package, or installed by Fleet in case of index patterns.
+func lintReferences(f savedObjectFile, available map[string]bool) []string {
+	var problems []string
+
+	index, err := f.object.GetValue("attributes.kibanaSavedObjectMeta.searchSourceJSON.index")
+	if err == nil {
+		problems = append(problems, fmt.Sprintf("index pattern %q is hard-coded in searchSourceJSON, use references instead", index))
+	}
+
+	references, _ := f.object["references"].([]interface{})
+	for _, r := range references {
+		reference, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		refType, _ := reference["type"].(string)
+		refID, _ := reference["id"].(string)
+
+		if refType == "index-pattern" {
+			if !available[refType+"/"+refID] && !common.StringSliceContains(fleetIndexPatterns, refID) {
+				problems = append(problems, fmt.Sprintf("referenced index pattern %q isn't shipped by the package (Fleet provides: %s)",
+					refID, strings.Join(fleetIndexPatterns, ", ")))
+			}
+			continue
+		}
+		if !available[refType+"/"+refID] {
+			problems = append(problems, fmt.Sprintf("referenced %s %q isn't shipped by the package (expected file: kibana/%s/%s.json)",
+				refType, refID, objectTypeDir(refType), refID))
+		}
+	}
+	return problems
+}
+
+// lintMarkdown checks links in markdown contents. Absolute URLs to Kibana don't work in other deployments,
+// and relative links to dashboards need to point to dashboards shipped by the package.
+func lintMarkdown(object common.MapStr, available map[string]bool) []string {
+	var problems []string
+	walkObject(object, func(key string, value interface{}) {
+		content, ok := value.(string)
+		if key != "markdown" || !ok {
+			return
+		}
+
+		for _, match := range markdownURLRegexp.FindAllStringSubmatch(content, -1) {
+			problems = append(problems, fmt.Sprintf("markdown contains absolute URL %q, use relative links instead (e.g. %s<id>)", match[1], dashboardLinkPrefix))
+		}
+		for _, match := range dashboardLinkID.FindAllStringSubmatch(content, -1) {
+			if !available["dashboard/"+match[1]] {
+				problems = append(problems, fmt.Sprintf("markdown links to dashboard %q, which isn't shipped by the package", match[1]))
+			}
+		}
+	})
+	return problems
+}
+
+// lintFields checks if fields used by visualizations and saved searches are defined in data streams.
+func lintFields(object common.MapStr, definitions []fields.FieldDefinition) []string {
+	used := map[string]bool{}
+	walkObject(object, func(key string, value interface{}) {
+		if name, ok := value.(string); ok && (key == "field" || key == "sourceField") {
+			used[name] = true
+		}
+	})
+	if object["type"] == "search" {
+		columns, _ := object.GetValue("attributes.columns")
+		list, _ := columns.([]interface{})
+		for _, c := range list {
+			if name, ok := c.(string); ok {
+				used[name] = true
+			}
+		}
+	}
+
+	var problems []string
+	for _, name := range sortedKeys(used) {
+		if name == "" || strings.HasPrefix(name, "_") || common.StringSliceContains(pseudoFields, name) || metricIDRegexp.MatchString(name) {
+			continue
+		}
+		if !isFieldDefined(name, definitions) {
+			problems = append(problems, fmt.Sprintf("field %q isn't defined in any data stream", name))
+		}
+	}
+	return problems
+}
+
+// isFieldDefined checks if the field is defined. Subfields of defined fields (e.g. multi-fields like "message.text")
+// are considered as defined too.
+func isFieldDefined(name string, definitions []fields.FieldDefinition) bool {
+	// Definitions of geo points match only their coordinates.
+	if fields.FindElementDefinition(name+".lat", definitions) != nil {
+		return true
+	}
+
+	for {
+		if definition := fields.FindElementDefinition(name, definitions); definition != nil {
+			return true
+		}
+
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+
+		definition := fields.FindElementDefinition(name, definitions)
+		if definition != nil {
+			return definition.Type != "" && definition.Type != "group" && definition.Type != "object"
+		}
+	}
+}
+
+// walkObject calls the function for every key-value pair of the object, including nested objects and arrays.
+func walkObject(value interface{}, fn func(key string, value interface{})) {
+	switch v := value.(type) {
+	case common.MapStr:
+		walkObject(map[string]interface{}(v), fn)
+	case map[string]interface{}:
+		for key, nested := range v {
+			fn(key, nested)
+			walkObject(nested, fn)
+		}
+	case []map[string]interface{}:
+		for _, nested := range v {
+			walkObject(nested, fn)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			walkObject(nested, fn)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
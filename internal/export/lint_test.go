@@ -0,0 +1,111 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintSavedObjects(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, packageRoot, "manifest.yml", "name: nginx\nversion: 1.0.0\n")
+	writeTestFile(t, packageRoot, "data_stream/access/fields/fields.yml", `
+- name: nginx.access
+  type: group
+  fields:
+    - name: remote_ip_list
+      type: keyword
+    - name: geoip.location
+      type: geo_point
+- name: message
+  type: text
+`)
+
+	writeTestFile(t, packageRoot, "kibana/dashboard/nginx-overview.json", `{
+  "id": "nginx-overview",
+  "type": "dashboard",
+  "attributes": {"title": "[Logs Nginx] Overview", "panelsJSON": "[]"},
+  "references": [
+    {"id": "nginx-access-map", "name": "panel_0", "type": "visualization"},
+    {"id": "nginx-navigation", "name": "panel_1", "type": "visualization"}
+  ]
+}`)
+	writeTestFile(t, packageRoot, "kibana/visualization/nginx-access-map.json", `{
+  "id": "nginx-access-map",
+  "type": "visualization",
+  "attributes": {
+    "title": "Access map",
+    "visState": "{\"aggs\":[{\"params\":{\"field\":\"nginx.access.geoip.location\"}},{\"params\":{\"field\":\"nginx.access.remote_ip_list\"}},{\"params\":{\"field\":\"message.keyword\"}}]}",
+    "kibanaSavedObjectMeta": {"searchSourceJSON": {"indexRefName": "kibanaSavedObjectMeta.searchSourceJSON.index"}}
+  },
+  "references": [{"id": "logs-*", "name": "kibanaSavedObjectMeta.searchSourceJSON.index", "type": "index-pattern"}]
+}`)
+	writeTestFile(t, packageRoot, "kibana/visualization/nginx-navigation.json", `{
+  "id": "nginx-navigation",
+  "type": "visualization",
+  "attributes": {
+    "title": "Navigation",
+    "visState": {"params": {"markdown": "[Overview](#/dashboard/nginx-overview) [Missing](#/dashboard/nginx-missing) [Abs](http://localhost:5601/app/kibana#/dashboard/nginx-overview)", "field": "nginx.access.unknown"}}
+  },
+  "references": []
+}`)
+	writeTestFile(t, packageRoot, "kibana/search/Errors.json", `{
+  "id": "Errors",
+  "type": "search",
+  "attributes": {
+    "title": "Errors",
+    "columns": ["message"],
+    "kibanaSavedObjectMeta": {"searchSourceJSON": {"index": "filebeat-*"}}
+  },
+  "references": [
+    {"id": "filebeat-*", "name": "kibanaSavedObjectMeta.searchSourceJSON.index", "type": "index-pattern"},
+    {"id": "nginx-tag", "name": "tag-0", "type": "tag"}
+  ]
+}`)
+	writeTestFile(t, packageRoot, "kibana/dashboard/nginx-untitled.json", `{
+  "id": "nginx-untitled",
+  "type": "dashboard",
+  "attributes": {"title": "Untitled"},
+  "references": []
+}`)
+
+	result, err := LintSavedObjects(packageRoot)
+	require.NoError(t, err)
+
+	assertProblems(t, []string{
+		`kibana/dashboard/nginx-untitled.json: dashboard title "Untitled" doesn't start with the "[Package] " prefix`,
+		`kibana/search/Errors.json: ID "Errors" isn't standardized, expected "nginx-Errors"`,
+		`kibana/search/Errors.json: index pattern "filebeat-*" is hard-coded in searchSourceJSON`,
+		`kibana/search/Errors.json: referenced index pattern "filebeat-*" isn't shipped by the package`,
+		`kibana/search/Errors.json: referenced tag "nginx-tag" isn't shipped by the package (expected file: kibana/tag/nginx-tag.json)`,
+		`kibana/visualization/nginx-navigation.json: markdown contains absolute URL "http://localhost:5601/app/kibana#/dashboard/nginx-overview"`,
+		`kibana/visualization/nginx-navigation.json: markdown links to dashboard "nginx-missing"`,
+	}, result.Errors.Error())
+
+	assertProblems(t, []string{
+		`kibana/visualization/nginx-navigation.json: field "nginx.access.unknown" isn't defined in any data stream`,
+	}, result.Warnings.Error())
+}
+
+func assertProblems(t *testing.T, expected []string, reported string) {
+	lines := strings.Split(strings.TrimSpace(reported), "\n")
+	assert.Len(t, lines, len(expected), reported)
+	for _, e := range expected {
+		assert.Contains(t, reported, e)
+	}
+}
+
+func writeTestFile(t *testing.T, root, path, content string) {
+	p := filepath.Join(root, filepath.FromSlash(path))
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+}
@@ -0,0 +1,71 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// checkMigrationVersion checks if the object has been migrated by Kibana newer than the given version.
+// Such objects are rejected by older Kibana versions.
+func checkMigrationVersion(object common.MapStr, kibanaVersion *semver.Version) []string {
+	versions := map[string]string{}
+	if migrationVersion, ok := object["migrationVersion"].(map[string]interface{}); ok {
+		for key, value := range migrationVersion {
+			if v, ok := value.(string); ok {
+				versions["migrationVersion."+key] = v
+			}
+		}
+	}
+	if v, ok := object["coreMigrationVersion"].(string); ok {
+		versions["coreMigrationVersion"] = v
+	}
+
+	var keys []string
+	for key := range versions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, key := range keys {
+		ver, err := semver.NewVersion(versions[key])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("invalid %s: %s", key, versions[key]))
+			continue
+		}
+		if ver.GreaterThan(kibanaVersion) {
+			problems = append(problems, fmt.Sprintf("%s (%s) is newer than the minimum Kibana version required by the package (%s), "+
+				"older Kibana versions can't load the object (export it from Kibana %s, e.g. using --target-version)",
+				key, ver, kibanaVersion, kibanaVersion))
+		}
+	}
+	return problems
+}
+
+// warnMigrationVersions warns about objects, which can't be loaded by Kibana versions supported by the package.
+func warnMigrationVersions(m packages.PackageManifest, objects []common.MapStr) {
+	kibanaVersion, err := packages.MinimumKibanaVersion(m)
+	if err != nil {
+		logger.Warnf("can't check migration versions of objects: %v", err)
+		return
+	}
+	if kibanaVersion == nil {
+		return
+	}
+
+	for _, object := range objects {
+		for _, problem := range checkMigrationVersion(object, kibanaVersion) {
+			logger.Warnf("%s %s: %s", object["type"], object["id"], problem)
+		}
+	}
+}
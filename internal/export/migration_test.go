@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+func TestCheckMigrationVersion(t *testing.T) {
+	kibanaVersion, err := semver.NewVersion("7.10.0")
+	require.NoError(t, err)
+
+	object := common.MapStr{
+		"id":                   "nginx-overview",
+		"type":                 "dashboard",
+		"migrationVersion":     map[string]interface{}{"dashboard": "7.9.3"},
+		"coreMigrationVersion": "7.10.0",
+	}
+	assert.Empty(t, checkMigrationVersion(object, kibanaVersion))
+
+	object["migrationVersion"] = map[string]interface{}{"dashboard": "7.14.0"}
+	object["coreMigrationVersion"] = "7.14.1"
+	problems := checkMigrationVersion(object, kibanaVersion)
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "coreMigrationVersion (7.14.1) is newer than the minimum Kibana version required by the package (7.10.0)")
+	assert.Contains(t, problems[1], "migrationVersion.dashboard (7.14.0) is newer")
+
+	assert.Empty(t, checkMigrationVersion(common.MapStr{"id": "nginx-overview", "type": "dashboard"}, kibanaVersion))
+}
@@ -17,6 +17,9 @@ type objectTransformer struct {
 
 type transformationContext struct {
 	packageName string
+
+	// includeIndexPatterns enables exporting index patterns, which are filtered out by default.
+	includeIndexPatterns bool
 }
 
 func newObjectTransformer() *objectTransformer {
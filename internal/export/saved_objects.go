@@ -0,0 +1,81 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/kibana"
+)
+
+// SupportedSavedObjectTypes are types of saved objects, which can be exported to the package.
+var SupportedSavedObjectTypes = []string{
+	"dashboard",
+	"index-pattern",
+	"lens",
+	"map",
+	"ml-module",
+	"search",
+	"tag",
+	"visualization",
+}
+
+// SavedObjectsOptions define saved objects to be exported.
+type SavedObjectsOptions struct {
+	Type string
+	IDs  []string
+
+	// WithReferences enables exporting all objects referenced by selected ones.
+	WithReferences bool
+
+	// WithIndexPatterns enables exporting referenced index patterns. Index patterns are usually provided by Fleet,
+	// so they're exported only for packages that ship custom ones.
+	WithIndexPatterns bool
+}
+
+// SavedObjects method exports selected saved objects of the given type, optionally with referenced objects.
+// All Kibana objects are saved to local files in appropriate directories.
+func SavedObjects(kibanaClient *kibana.Client, options SavedObjectsOptions) error {
+	err := ValidateSavedObjectType(options.Type)
+	if err != nil {
+		return err
+	}
+
+	packageRoot, m, err := findPackage()
+	if err != nil {
+		return err
+	}
+
+	var references []kibana.SavedObjectReference
+	for _, id := range options.IDs {
+		references = append(references, kibana.SavedObjectReference{Type: options.Type, ID: id})
+	}
+
+	objects, err := kibanaClient.ExportSavedObjects(references, options.WithReferences)
+	if err != nil {
+		return errors.Wrap(err, "exporting saved objects using Kibana client failed")
+	}
+
+	ctx := &transformationContext{
+		packageName: m.Name,
+
+		// Index patterns requested explicitly are always exported.
+		includeIndexPatterns: options.WithIndexPatterns || options.Type == "index-pattern",
+	}
+	return transformAndSaveObjects(ctx, packageRoot, *m, objects)
+}
+
+// ValidateSavedObjectType checks if saved objects of the type can be exported.
+func ValidateSavedObjectType(objectType string) error {
+	for _, t := range SupportedSavedObjectTypes {
+		if t == objectType {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported saved object type: %q (supported types: %s)", objectType, strings.Join(SupportedSavedObjectTypes, ", "))
+}
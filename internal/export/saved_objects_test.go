@@ -0,0 +1,62 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+func TestTransform_IndexPatterns(t *testing.T) {
+	objects := func() []common.MapStr {
+		return []common.MapStr{
+			{
+				"id":         "logs-*",
+				"type":       "index-pattern",
+				"attributes": map[string]interface{}{"title": "logs-*"},
+				"references": []interface{}{},
+			},
+			{
+				"id":         "overview",
+				"type":       "search",
+				"attributes": map[string]interface{}{"title": "Overview"},
+				"references": []interface{}{
+					map[string]interface{}{"id": "logs-*", "name": "kibanaSavedObjectMeta.searchSourceJSON.index", "type": "index-pattern"},
+				},
+			},
+		}
+	}
+
+	results, err := applyTransformations(&transformationContext{packageName: "nginx"}, objects())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "nginx-overview", results[0]["id"])
+
+	results, err = applyTransformations(&transformationContext{packageName: "nginx", includeIndexPatterns: true}, objects())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "logs-*", results[0]["id"], "index pattern IDs are not adjusted")
+	assert.Equal(t, "nginx-overview", results[1]["id"])
+
+	reference := results[1]["references"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "logs-*", reference["id"])
+}
+
+func TestValidateSavedObjectType(t *testing.T) {
+	for _, objectType := range []string{"dashboard", "index-pattern", "lens", "map", "ml-module", "search", "tag", "visualization"} {
+		assert.NoError(t, ValidateSavedObjectType(objectType), objectType)
+	}
+	assert.Error(t, ValidateSavedObjectType("config"))
+}
+
+func TestObjectTypeDir(t *testing.T) {
+	assert.Equal(t, "visualization", objectTypeDir("visualization"))
+	assert.Equal(t, "index_pattern", objectTypeDir("index-pattern"))
+	assert.Equal(t, "ml_module", objectTypeDir("ml-module"))
+}
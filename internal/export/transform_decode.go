@@ -33,15 +33,21 @@ func decodeObject(ctx *transformationContext, object common.MapStr) (common.MapS
 			return nil, errors.Wrapf(err, "retrieving value failed (key: %s)", fieldToDecode)
 		}
 
+		// Objects stored in the package have fields already decoded.
+		encoded, isString := v.(string)
+		if !isString {
+			continue
+		}
+
 		var target interface{}
 		var single map[string]interface{}
 		var array []map[string]interface{}
 
-		err = json.Unmarshal([]byte(v.(string)), &single)
+		err = json.Unmarshal([]byte(encoded), &single)
 		if err == nil {
 			target = single
 		} else {
-			err = json.Unmarshal([]byte(v.(string)), &array)
+			err = json.Unmarshal([]byte(encoded), &array)
 			if err != nil {
 				return nil, errors.Wrapf(err, "can't unmarshal encoded field (key: %s)", fieldToDecode)
 			}
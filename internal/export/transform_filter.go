@@ -11,7 +11,10 @@ import (
 func filterUnsupportedTypes(ctx *transformationContext, object common.MapStr) (common.MapStr, error) {
 	aType, _ := object.GetValue("type")
 	switch aType {
-	case "index-pattern": // unsupported types
+	case "index-pattern": // unsupported types, unless requested
+		if ctx.includeIndexPatterns {
+			return object, nil
+		}
 		return nil, nil
 	default:
 		return object, nil
@@ -16,16 +16,21 @@ import (
 const dashboardLinkPrefix = "#/dashboard/"
 
 func standardizeObjectID(ctx *transformationContext, object common.MapStr) (common.MapStr, error) {
-	// Adjust object ID
+	// Adjust object ID, except index patterns as references to them are never modified.
 	id, _ := object.GetValue("id")
-	_, err := object.Put("id", adjustObjectID(ctx, id.(string)))
-	if err != nil {
-		return nil, errors.Wrapf(err, "can't update object ID")
+	if aType, _ := object.GetValue("type"); aType != "index-pattern" {
+		_, err := object.Put("id", adjustObjectID(ctx, id.(string)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't update object ID")
+		}
 	}
 
 	// Adjust references
 	references, err := object.GetValue("references")
-	if err != nil && err != common.ErrKeyNotFound {
+	if err == common.ErrKeyNotFound {
+		return object, nil
+	}
+	if err != nil {
 		return nil, errors.Wrap(err, "retrieving object references failed")
 	}
 
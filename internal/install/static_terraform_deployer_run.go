@@ -14,7 +14,9 @@ cleanup() {
   r=$?
 
   set -x
-  terraform destroy -auto-approve
+  if [ "${TF_KEEP_STATE:-false}" != "true" ]; then
+    terraform destroy -auto-approve
+  fi
 
   exit $r
 }
@@ -22,7 +24,8 @@ trap cleanup EXIT INT TERM
 
 terraform init
 terraform plan
-terraform apply -auto-approve && touch /tmp/tf-applied
+terraform apply -auto-approve
+terraform output -json > /workspace/outputs.json && touch /tmp/tf-applied
 
 echo "Terraform definitions applied."
 
@@ -11,6 +11,10 @@ services:
     tty: true
     environment:
       - TF_VAR_TEST_RUN_ID=${TF_VAR_TEST_RUN_ID:-detached}
+      - TF_KEEP_STATE=${TF_KEEP_STATE:-false}
+    env_file:
+      - ${TF_ENV_FILE}
     volumes:
       - ${TF_DIR}:/stage
+      - ${TF_WORKSPACE_DIR}:/workspace
 `
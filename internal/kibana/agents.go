@@ -16,13 +16,20 @@ import (
 
 // Agent represents an Elastic Agent enrolled with fleet.
 type Agent struct {
-	ID             string `json:"id"`
-	PolicyID       string `json:"policy_id"`
-	PolicyRevision int    `json:"policy_revision,omitempty"`
+	ID             string   `json:"id"`
+	PolicyID       string   `json:"policy_id"`
+	PolicyRevision int      `json:"policy_revision,omitempty"`
+	Status         string   `json:"status,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
 	LocalMetadata  struct {
 		Host struct {
 			Name string `json:"name"`
 		} `json:"host"`
+		Elastic struct {
+			Agent struct {
+				Version string `json:"version"`
+			} `json:"agent"`
+		} `json:"elastic"`
 	} `json:"local_metadata"`
 }
 
@@ -6,6 +6,7 @@ package kibana
 
 import (
 	"bytes"
+	"crypto/tls"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -24,6 +25,9 @@ type Client struct {
 	host     string
 	username string
 	password string
+	apiKey   string
+
+	tlsConfig *tls.Config
 }
 
 // NewClient creates a new instance of the client.
@@ -36,10 +40,17 @@ func NewClient() (*Client, error) {
 	username := os.Getenv(stack.ElasticsearchUsernameEnv)
 	password := os.Getenv(stack.ElasticsearchPasswordEnv)
 
+	tlsConfig, err := stack.TLSConfig(os.Getenv(stack.CACertificateEnv))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't create TLS configuration")
+	}
+
 	return &Client{
-		host:     host,
-		username: username,
-		password: password,
+		host:      host,
+		username:  username,
+		password:  password,
+		apiKey:    os.Getenv(stack.ElasticsearchAPIKeyEnv),
+		tlsConfig: tlsConfig,
 	}, nil
 }
 
@@ -60,6 +71,10 @@ func (c *Client) delete(resourcePath string) (int, []byte, error) {
 }
 
 func (c *Client) sendRequest(method, resourcePath string, body []byte) (int, []byte, error) {
+	return c.sendRequestWithContentType(method, resourcePath, body, "application/json")
+}
+
+func (c *Client) sendRequestWithContentType(method, resourcePath string, body []byte, contentType string) (int, []byte, error) {
 	reqBody := bytes.NewReader(body)
 	base, err := url.Parse(c.host)
 	if err != nil {
@@ -80,11 +95,20 @@ func (c *Client) sendRequest(method, resourcePath string, body []byte) (int, []b
 		return 0, nil, errors.Wrapf(err, "could not create %v request to Kibana API resource: %s", method, resourcePath)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
-	req.Header.Add("content-type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+c.apiKey)
+	} else {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Add("content-type", contentType)
 	req.Header.Add("kbn-xsrf", install.DefaultStackVersion)
 
 	client := http.Client{}
+	if c.tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = c.tlsConfig
+		client.Transport = transport
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return 0, nil, errors.Wrap(err, "could not send request to Kibana API")
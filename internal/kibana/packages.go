@@ -7,6 +7,7 @@ package kibana
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/pkg/errors"
 
@@ -26,6 +27,18 @@ func (c *Client) InstallPackage(pkg packages.PackageManifest) ([]packages.Asset,
 	return processResults("install", statusCode, respBody)
 }
 
+// UploadPackage installs the package from the zip archive in Fleet. It's used when the package can't be served
+// by the package registry used by Kibana (e.g. remote stacks).
+func (c *Client) UploadPackage(archive []byte) ([]packages.Asset, error) {
+	path := fmt.Sprintf("%s/epm/packages", FleetAPI)
+	statusCode, respBody, err := c.sendRequestWithContentType(http.MethodPost, path, archive, "application/zip")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not upload package")
+	}
+
+	return processResults("upload", statusCode, respBody)
+}
+
 // RemovePackage removes the given package from Fleet.
 func (c *Client) RemovePackage(pkg packages.PackageManifest) ([]packages.Asset, error) {
 	path := fmt.Sprintf("%s/epm/packages/%s-%s", FleetAPI, pkg.Name, pkg.Version)
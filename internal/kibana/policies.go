@@ -71,6 +71,29 @@ func (c *Client) GetPolicy(policyID string) (*Policy, error) {
 	return &resp.Item, nil
 }
 
+// GetFullPolicy fetches the full agent policy, as rendered by Fleet and sent to agents.
+// The policy is returned in the raw JSON form.
+func (c *Client) GetFullPolicy(policyID string) (json.RawMessage, error) {
+	statusCode, respBody, err := c.get(fmt.Sprintf("%s/agent_policies/%s/full", FleetAPI, policyID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get full policy")
+	}
+
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not get full policy; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var resp struct {
+		Item json.RawMessage `json:"item"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "could not convert full policy (response) to JSON")
+	}
+
+	return resp.Item, nil
+}
+
 // DeletePolicy removes the given Policy from the Ingest Manager.
 func (c *Client) DeletePolicy(p Policy) error {
 	reqBody := `{ "agentPolicyId": "` + p.ID + `" }`
@@ -115,10 +138,11 @@ type Stream struct {
 
 // Input represents a package-level input.
 type Input struct {
-	Type    string   `json:"type"`
-	Enabled bool     `json:"enabled"`
-	Streams []Stream `json:"streams"`
-	Vars    Vars     `json:"vars"`
+	PolicyTemplate string   `json:"policy_template,omitempty"`
+	Type           string   `json:"type"`
+	Enabled        bool     `json:"enabled"`
+	Streams        []Stream `json:"streams"`
+	Vars           Vars     `json:"vars"`
 }
 
 // PackageDataStream represents a request to add a single package's single data stream to a
@@ -5,6 +5,8 @@
 package kibana
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/elastic/elastic-package/internal/common"
 	"github.com/elastic/elastic-package/internal/logger"
 )
 
@@ -104,3 +107,67 @@ func (c *Client) findDashboardsNextPage(page int) (*savedObjectsResponse, error)
 	}
 	return &r, nil
 }
+
+// SavedObjectReference identifies the saved object by its type and ID.
+type SavedObjectReference struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type exportSavedObjectsRequest struct {
+	Objects               []SavedObjectReference `json:"objects"`
+	IncludeReferencesDeep bool                   `json:"includeReferencesDeep"`
+	ExcludeExportDetails  bool                   `json:"excludeExportDetails"`
+}
+
+// ExportSavedObjects method exports selected saved objects using the Saved Objects API. If withReferences is set,
+// all objects referenced by selected ones (directly or indirectly) are exported too.
+func (c *Client) ExportSavedObjects(objects []SavedObjectReference, withReferences bool) ([]common.MapStr, error) {
+	logger.Debug("Export saved objects using the Saved Objects API")
+
+	reqBody, err := json.Marshal(exportSavedObjectsRequest{
+		Objects:               objects,
+		IncludeReferencesDeep: withReferences,
+		ExcludeExportDetails:  true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling request failed")
+	}
+
+	statusCode, respBody, err := c.post(fmt.Sprintf("%s/_export", SavedObjectsAPI), reqBody)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not export saved objects; API status code = %d; response body = %s", statusCode, string(respBody))
+	}
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not export saved objects; API status code = %d; response body = %s", statusCode, string(respBody))
+	}
+	return parseExportedSavedObjects(respBody)
+}
+
+// parseExportedSavedObjects parses the NDJSON response of the export API, one saved object per line.
+// The summary of the export is skipped if it's present.
+func parseExportedSavedObjects(body []byte) ([]common.MapStr, error) {
+	var objects []common.MapStr
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var object common.MapStr
+		err := json.Unmarshal(line, &object)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unmarshalling saved object failed (line: %s)", string(line))
+		}
+		if _, found := object["exportedCount"]; found {
+			continue
+		}
+		objects = append(objects, object)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading response failed")
+	}
+	return objects, nil
+}
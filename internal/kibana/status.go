@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Status describes the overall status of Kibana.
+type Status struct {
+	Version string
+	Overall string // e.g. "green" (7.x) or "available" (8.x)
+}
+
+// Healthy method returns true if Kibana reports the green or available overall status.
+func (s Status) Healthy() bool {
+	return s.Overall == "green" || s.Overall == "available"
+}
+
+// FleetSetupStatus describes whether Fleet is ready to enroll agents.
+type FleetSetupStatus struct {
+	IsReady             bool     `json:"isReady"`
+	MissingRequirements []string `json:"missing_requirements"`
+}
+
+// Status method returns the overall status of Kibana.
+func (c *Client) Status() (*Status, error) {
+	statusCode, respBody, err := c.get("/api/status")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get Kibana status")
+	}
+
+	if statusCode != 200 && statusCode != 503 {
+		return nil, fmt.Errorf("could not get Kibana status; API status code = %d; response body = %s", statusCode, string(respBody))
+	}
+
+	var resp struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+		Status struct {
+			Overall struct {
+				State string `json:"state"`
+				Level string `json:"level"`
+			} `json:"overall"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, errors.Wrap(err, "could not convert Kibana status (response) to JSON")
+	}
+
+	status := Status{
+		Version: resp.Version.Number,
+		Overall: resp.Status.Overall.State,
+	}
+	if status.Overall == "" {
+		status.Overall = resp.Status.Overall.Level
+	}
+	return &status, nil
+}
+
+// FleetSetupStatus method returns the status of Fleet setup, including Fleet Server requirements.
+func (c *Client) FleetSetupStatus() (*FleetSetupStatus, error) {
+	statusCode, respBody, err := c.get(fmt.Sprintf("%s/agents/setup", FleetAPI))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get Fleet setup status")
+	}
+
+	if statusCode != 200 {
+		return nil, fmt.Errorf("could not get Fleet setup status; API status code = %d; response body = %s", statusCode, string(respBody))
+	}
+
+	var status FleetSetupStatus
+	if err := json.Unmarshal(respBody, &status); err != nil {
+		return nil, errors.Wrap(err, "could not convert Fleet setup status (response) to JSON")
+	}
+	return &status, nil
+}
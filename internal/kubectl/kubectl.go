@@ -45,7 +45,7 @@ func modifyKubernetesResources(action string, definitionPaths ...string) ([]byte
 	logger.Debugf("run command: %s", cmd)
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, errors.Wrapf(err, "kubectl apply failed (stderr=%q)", errOutput.String())
+		return nil, errors.Wrapf(err, "kubectl %s failed (stderr=%q)", action, errOutput.String())
 	}
 	return output, nil
 }
@@ -5,7 +5,9 @@
 package kubectl
 
 import (
+	"bytes"
 	"fmt"
+	"os/exec"
 	"strings"
 	"time"
 
@@ -84,6 +86,34 @@ func Apply(definitionPaths ...string) error {
 	return nil
 }
 
+// ApplyStdin function adds resources to the Kubernetes cluster based on definitions passed
+// to the standard input. Resources without a namespace are created in the given namespace (optional).
+func ApplyStdin(definitions []byte, namespace string) error {
+	logger.Debugf("Apply Kubernetes definitions (namespace: %s)", namespace)
+	definitions, err := setDefaultNamespace(definitions, namespace)
+	if err != nil {
+		return errors.Wrap(err, "can't set namespace of definitions")
+	}
+
+	cmd := exec.Command("kubectl", "apply", "-f", "-", "-o", "yaml")
+	cmd.Stdin = bytes.NewReader(definitions)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(err, "kubectl apply failed (stderr=%q)", errOutput.String())
+	}
+
+	logger.Debugf("Handle \"apply\" command output")
+	err = handleApplyCommandOutput(out)
+	if err != nil {
+		return errors.Wrap(err, "can't handle command output")
+	}
+	return nil
+}
+
 func handleApplyCommandOutput(out []byte) error {
 	logger.Debugf("Extract resources from command output")
 	resources, err := extractResources(out)
@@ -4,8 +4,40 @@
 
 package kubectl
 
+import (
+	"bytes"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
 // Delete function removes resources from the Kubernetes cluster based on provided definitions.
 func Delete(definitionPaths ...string) error {
 	_, err := modifyKubernetesResources("delete", definitionPaths...)
 	return err
 }
+
+// DeleteStdin function removes resources from the Kubernetes cluster based on definitions passed
+// to the standard input. Resources without a namespace are looked up in the given namespace (optional).
+// Resources, which don't exist, are ignored.
+func DeleteStdin(definitions []byte, namespace string) error {
+	logger.Debugf("Delete Kubernetes definitions (namespace: %s)", namespace)
+	definitions, err := setDefaultNamespace(definitions, namespace)
+	if err != nil {
+		return errors.Wrap(err, "can't set namespace of definitions")
+	}
+
+	cmd := exec.Command("kubectl", "delete", "-f", "-", "--ignore-not-found")
+	cmd.Stdin = bytes.NewReader(definitions)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "kubectl delete failed (stderr=%q)", errOutput.String())
+	}
+	return nil
+}
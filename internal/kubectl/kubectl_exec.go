@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kubectl
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// Pod identifies a pod running in the Kubernetes cluster.
+type Pod struct {
+	Name      string
+	Namespace string
+}
+
+func (p Pod) String() string {
+	return p.Namespace + "/" + p.Name
+}
+
+// FindPods function returns pods matching the label selector (optional) in the namespace.
+// If the namespace is empty, pods are searched in all namespaces.
+func FindPods(namespace, selector string) ([]Pod, error) {
+	args := []string{"get", "pods"}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	if selector != "" {
+		args = append(args, "-l", selector)
+	}
+	args = append(args, "-o", `jsonpath={range .items[*]}{.metadata.namespace}{" "}{.metadata.name}{"\n"}{end}`)
+
+	cmd := exec.Command("kubectl", args...)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "kubectl get failed (stderr=%q)", errOutput.String())
+	}
+	return parsePods(output), nil
+}
+
+func parsePods(output []byte) []Pod {
+	var pods []Pod
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		pods = append(pods, Pod{Namespace: fields[0], Name: fields[1]})
+	}
+	return pods
+}
+
+// Exec function runs the command in the first container of the pod.
+func Exec(pod Pod, command ...string) error {
+	args := []string{"exec", pod.Name, "-n", pod.Namespace, "--"}
+	args = append(args, command...)
+
+	cmd := exec.Command("kubectl", args...)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(err, "kubectl exec failed (stdout=%q, stderr=%q)", string(output), errOutput.String())
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kubectl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePods(t *testing.T) {
+	pods := parsePods([]byte("default nginx-6799fc88d8-2xbkw\nkube-system kube-state-metrics-5c5cb55b4-xhcd7\n\n"))
+
+	assert.Equal(t, []Pod{
+		{Namespace: "default", Name: "nginx-6799fc88d8-2xbkw"},
+		{Namespace: "kube-system", Name: "kube-state-metrics-5c5cb55b4-xhcd7"},
+	}, pods)
+}
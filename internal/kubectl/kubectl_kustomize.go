@@ -0,0 +1,28 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kubectl
+
+import (
+	"bytes"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// Kustomize function renders manifests of the kustomization stored in the directory.
+func Kustomize(dir string) ([]byte, error) {
+	cmd := exec.Command("kubectl", "kustomize", dir)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "kubectl kustomize failed (stderr=%q)", errOutput.String())
+	}
+	return output, nil
+}
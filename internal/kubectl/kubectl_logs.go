@@ -0,0 +1,45 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kubectl
+
+import (
+	"bytes"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// Logs function returns logs of all containers in pods matching the label selector (optional) in the namespace.
+func Logs(namespace, selector string) ([]byte, error) {
+	pods, err := FindPods(namespace, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't find pods")
+	}
+
+	var b bytes.Buffer
+	for _, pod := range pods {
+		logs, err := podLogs(pod)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't read logs (pod: %s)", pod)
+		}
+		b.Write(logs)
+	}
+	return b.Bytes(), nil
+}
+
+func podLogs(pod Pod) ([]byte, error) {
+	cmd := exec.Command("kubectl", "logs", pod.Name, "-n", pod.Namespace, "--all-containers", "--prefix")
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "kubectl logs failed (stderr=%q)", errOutput.String())
+	}
+	return output, nil
+}
@@ -0,0 +1,189 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kubectl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const (
+	// TestRunLabel is the label of namespaces created for system test runs.
+	// Its value is the ID of the test run.
+	TestRunLabel = "elastic-package/test-run"
+
+	testNamespacePrefix = "elastic-package-test"
+)
+
+// Namespace describes the namespace of the Kubernetes cluster.
+type Namespace struct {
+	Name      string
+	CreatedAt time.Time
+}
+
+type namespaceDefinition struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name   string            `yaml:"name"`
+		Labels map[string]string `yaml:"labels,omitempty"`
+	} `yaml:"metadata"`
+}
+
+// CreateNamespace function creates the namespace with given labels and waits until it's ready.
+func CreateNamespace(name string, labels map[string]string) error {
+	var ns namespaceDefinition
+	ns.APIVersion = "v1"
+	ns.Kind = "Namespace"
+	ns.Metadata.Name = name
+	ns.Metadata.Labels = labels
+
+	definition, err := yaml.Marshal(&ns)
+	if err != nil {
+		return errors.Wrap(err, "can't marshal namespace definition")
+	}
+
+	err = ApplyStdin(definition, "")
+	if err != nil {
+		return errors.Wrapf(err, "can't create namespace (name: %s)", name)
+	}
+	return nil
+}
+
+// TestNamespace function returns the name of the namespace dedicated to the test run.
+func TestNamespace(runID string) string {
+	return fmt.Sprintf("%s-%s", testNamespacePrefix, runID)
+}
+
+// DeleteNamespace function removes the namespace including all resources in it.
+func DeleteNamespace(name string) error {
+	cmd := exec.Command("kubectl", "delete", "namespace", name)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "kubectl delete failed (stderr=%q)", errOutput.String())
+	}
+	return nil
+}
+
+// FindNamespaces function returns namespaces matching the label selector.
+func FindNamespaces(selector string) ([]Namespace, error) {
+	cmd := exec.Command("kubectl", "get", "namespaces", "-l", selector,
+		"-o", `jsonpath={range .items[*]}{.metadata.name}{" "}{.metadata.creationTimestamp}{"\n"}{end}`)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "kubectl get failed (stderr=%q)", errOutput.String())
+	}
+	return parseNamespaces(output)
+}
+
+func parseNamespaces(output []byte) ([]Namespace, error) {
+	var namespaces []Namespace
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't parse creation timestamp of namespace %s", fields[0])
+		}
+		namespaces = append(namespaces, Namespace{Name: fields[0], CreatedAt: createdAt})
+	}
+	return namespaces, nil
+}
+
+// setDefaultNamespace sets the namespace of resources, which don't define one. Resources with an explicit
+// namespace (e.g. kube-system) are left untouched. Cluster-scoped resources ignore the namespace.
+func setDefaultNamespace(definitions []byte, namespace string) ([]byte, error) {
+	if namespace == "" {
+		return definitions, nil
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(definitions))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "can't decode definitions")
+		}
+		if len(document.Content) == 0 || document.Content[0].ShortTag() == "!!null" {
+			continue // empty document
+		}
+
+		setResourceNamespace(document.Content[0], namespace)
+		err = encoder.Encode(&document)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't encode definitions")
+		}
+	}
+
+	err := encoder.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't encode definitions")
+	}
+	return b.Bytes(), nil
+}
+
+func setResourceNamespace(node *yaml.Node, namespace string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	if items := mappingValue(node, "items"); items != nil && items.Kind == yaml.SequenceNode {
+		for _, item := range items.Content {
+			setResourceNamespace(item, namespace)
+		}
+	}
+
+	metadata := mappingValue(node, "metadata")
+	if metadata == nil || metadata.Kind != yaml.MappingNode {
+		return
+	}
+
+	if ns := mappingValue(metadata, "namespace"); ns != nil {
+		if ns.Value == "" {
+			ns.Tag, ns.Value = "!!str", namespace
+		}
+		return
+	}
+	metadata.Content = append(metadata.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "namespace"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: namespace},
+	)
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kubectl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaultNamespace(t *testing.T) {
+	definitions := `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-state-metrics
+  namespace: kube-system
+---
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: config
+      namespace:
+`
+
+	result, err := setDefaultNamespace([]byte(definitions), "elastic-package-test-12345")
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+  namespace: elastic-package-test-12345
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-state-metrics
+  namespace: kube-system
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: config
+      namespace: elastic-package-test-12345
+`, string(result))
+}
+
+func TestSetDefaultNamespace_noNamespace(t *testing.T) {
+	definitions := "apiVersion: v1\nkind: Service\nmetadata:\n  name: nginx\n"
+
+	result, err := setDefaultNamespace([]byte(definitions), "")
+	require.NoError(t, err)
+	assert.Equal(t, definitions, string(result))
+}
+
+func TestParseNamespaces(t *testing.T) {
+	namespaces, err := parseNamespaces([]byte("elastic-package-test-12345 2021-09-01T10:20:30Z\n\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Namespace{
+		{Name: "elastic-package-test-12345", CreatedAt: time.Date(2021, 9, 1, 10, 20, 30, 0, time.UTC)},
+	}, namespaces)
+}
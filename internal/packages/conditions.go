@@ -6,6 +6,7 @@ package packages
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/Masterminds/semver"
@@ -17,6 +18,8 @@ import (
 
 const kibanaVersionRequirement = "kibana.version"
 
+var constraintVersionRegexp = regexp.MustCompile(`\d+(\.\d+){0,2}`)
+
 type packageRequirements struct {
 	kibana struct {
 		version *semver.Version
@@ -78,3 +81,35 @@ func parsePackageRequirements(keyValuePairs []string) (*packageRequirements, err
 	}
 	return &pr, nil
 }
+
+// MinimumKibanaVersion returns the lowest Kibana version satisfying the kibana.version condition of the package,
+// e.g. 7.14.0 for "^7.14.0 || ^8.0.0". Only versions mentioned in the condition are considered. It returns nil
+// if the package doesn't define the condition.
+func MinimumKibanaVersion(manifest PackageManifest) (*semver.Version, error) {
+	if len(manifest.Conditions.Kibana.Version) == 0 {
+		return nil, nil
+	}
+
+	kibanaConstraint, err := semver.NewConstraint(manifest.Conditions.Kibana.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid constraint for Kibana")
+	}
+
+	var minimum *semver.Version
+	for _, candidate := range constraintVersionRegexp.FindAllString(manifest.Conditions.Kibana.Version, -1) {
+		ver, err := semver.NewVersion(candidate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't parse version %s", candidate)
+		}
+		if !kibanaConstraint.Check(ver) {
+			continue
+		}
+		if minimum == nil || ver.LessThan(minimum) {
+			minimum = ver
+		}
+	}
+	if minimum == nil {
+		return nil, fmt.Errorf("can't find the minimum Kibana version satisfying the constraint %s", manifest.Conditions.Kibana.Version)
+	}
+	return minimum, nil
+}
@@ -57,3 +57,31 @@ func TestCheckConditions_ValidSnapshot(t *testing.T) {
 	err := CheckConditions(manifest, []string{"kibana.version=7.11.1-SNAPSHOT"})
 	assert.NoError(t, err)
 }
+
+func TestMinimumKibanaVersion(t *testing.T) {
+	for constraint, expected := range map[string]string{
+		"^7.14.0":            "7.14.0",
+		"^7.14.0 || ^8.0.0":  "7.14.0",
+		">=7.10.0, <8.0.0":   "7.10.0",
+		"~7.11.1 || >=7.9.0": "7.9.0",
+	} {
+		manifest := PackageManifest{
+			Conditions: Conditions{
+				Kibana: KibanaConditions{Version: constraint},
+			},
+		}
+
+		minimum, err := MinimumKibanaVersion(manifest)
+		assert.NoError(t, err, constraint)
+		if assert.NotNil(t, minimum, constraint) {
+			assert.Equal(t, expected, minimum.String(), constraint)
+		}
+	}
+
+	minimum, err := MinimumKibanaVersion(PackageManifest{})
+	assert.NoError(t, err)
+	assert.Nil(t, minimum)
+
+	_, err = MinimumKibanaVersion(PackageManifest{Conditions: Conditions{Kibana: KibanaConditions{Version: ">7.10.0"}}})
+	assert.Error(t, err)
+}
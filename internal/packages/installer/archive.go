@@ -0,0 +1,58 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package installer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// zipPackage function packs the built package into the zip archive, as expected by the Fleet upload API.
+// All files are stored in the top-level directory "<name>-<version>".
+func zipPackage(builtPackagePath, rootDir string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	err := filepath.Walk(builtPackagePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(builtPackagePath, p)
+		if err != nil {
+			return errors.Wrapf(err, "can't find relative path (path: %s)", p)
+		}
+
+		body, err := ioutil.ReadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "can't read file (path: %s)", p)
+		}
+
+		fw, err := w.Create(path.Join(rootDir, filepath.ToSlash(rel)))
+		if err != nil {
+			return errors.Wrapf(err, "can't add file to archive (path: %s)", rel)
+		}
+		_, err = fw.Write(body)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't pack the package (path: %s)", builtPackagePath)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't close archive")
+	}
+	return buf.Bytes(), nil
+}
@@ -5,10 +5,14 @@
 package installer
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 
+	"github.com/elastic/elastic-package/internal/builder"
 	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/profile"
 )
 
 // Installer is responsible for installation/uninstallation of the package.
@@ -16,6 +20,9 @@ type Installer struct {
 	manifest packages.PackageManifest
 
 	kibanaClient *kibana.Client
+
+	// upload enables installing the built package using the Fleet upload API, instead of the package registry.
+	upload bool
 }
 
 // InstalledPackage represents the installed package (including assets).
@@ -24,22 +31,40 @@ type InstalledPackage struct {
 	Manifest packages.PackageManifest
 }
 
-// CreateForManifest function creates a new instance of the installer.
-func CreateForManifest(manifest packages.PackageManifest) (*Installer, error) {
+// CreateForManifest function creates a new instance of the installer. Packages are uploaded to Kibana
+// if the profile targets a remote stack, as they can't be served by its package registry. The profile
+// is optional, packages aren't uploaded without it.
+func CreateForManifest(manifest packages.PackageManifest, elasticStackProfile *profile.Profile) (*Installer, error) {
 	kibanaClient, err := kibana.NewClient()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create kibana client")
 	}
 
+	var upload bool
+	if elasticStackProfile != nil {
+		config, err := elasticStackProfile.Config()
+		if err != nil {
+			return nil, errors.Wrap(err, "can't read profile configuration")
+		}
+		upload = config.IsRemote()
+	}
+
 	return &Installer{
 		manifest:     manifest,
 		kibanaClient: kibanaClient,
+		upload:       upload,
 	}, nil
 }
 
 // Install method installs the package using Kibana API.
 func (i *Installer) Install() (*InstalledPackage, error) {
-	assets, err := i.kibanaClient.InstallPackage(i.manifest)
+	var assets []packages.Asset
+	var err error
+	if i.upload {
+		assets, err = i.uploadPackage()
+	} else {
+		assets, err = i.kibanaClient.InstallPackage(i.manifest)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "can't install the package")
 	}
@@ -58,3 +83,17 @@ func (i *Installer) Uninstall() error {
 	}
 	return nil
 }
+
+// uploadPackage builds the package and uploads it to Kibana.
+func (i *Installer) uploadPackage() ([]packages.Asset, error) {
+	builtPackagePath, err := builder.BuildPackage()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't build the package")
+	}
+
+	archive, err := zipPackage(builtPackagePath, fmt.Sprintf("%s-%s", i.manifest.Name, i.manifest.Version))
+	if err != nil {
+		return nil, err
+	}
+	return i.kibanaClient.UploadPackage(archive)
+}
@@ -62,6 +62,16 @@ func (vv VarValue) MarshalJSON() ([]byte, error) {
 	return []byte("null"), nil
 }
 
+// Value returns the variable value, either a scalar or a list.
+func (vv VarValue) Value() interface{} {
+	if vv.scalar != nil {
+		return vv.scalar
+	} else if vv.list != nil {
+		return vv.list
+	}
+	return nil
+}
+
 // Variable is an instance of configuration variable (named, typed).
 type Variable struct {
 	Name    string   `config:"name" json:"name" yaml:"name"`
@@ -87,6 +97,7 @@ type Conditions struct {
 
 // PolicyTemplate is a configuration of inputs responsible for collecting log or metric data.
 type PolicyTemplate struct {
+	Name   string  `config:"name" json:"name" yaml:"name"`
 	Inputs []Input `config:"inputs" json:"inputs" yaml:"inputs"`
 }
 
@@ -124,8 +135,9 @@ type DataStreamManifest struct {
 		} `config:"ingest_pipeline" json:"ingest_pipeline" yaml:"ingest_pipeline"`
 	} `config:"elasticsearch" json:"elasticsearch" yaml:"elasticsearch"`
 	Streams []struct {
-		Input string     `config:"input" json:"input" yaml:"input"`
-		Vars  []Variable `config:"vars" json:"vars" yaml:"vars"`
+		Input        string     `config:"input" json:"input" yaml:"input"`
+		TemplatePath string     `config:"template_path" json:"template_path" yaml:"template_path"`
+		Vars         []Variable `config:"vars" json:"vars" yaml:"vars"`
 	} `config:"streams" json:"streams" yaml:"streams"`
 }
 
@@ -251,6 +263,25 @@ func (dsm *DataStreamManifest) IndexTemplateName(pkgName string) string {
 	return fmt.Sprintf("%s-%s", dsm.Type, dsm.Dataset)
 }
 
+// FindPolicyTemplate returns the policy template with the given name. If the name is empty,
+// the first policy template is returned.
+func (pm *PackageManifest) FindPolicyTemplate(name string) (*PolicyTemplate, error) {
+	if len(pm.PolicyTemplates) == 0 {
+		return nil, errors.New("package doesn't define any policy templates")
+	}
+
+	if name == "" {
+		return &pm.PolicyTemplates[0], nil
+	}
+
+	for _, pt := range pm.PolicyTemplates {
+		if pt.Name == name {
+			return &pt, nil
+		}
+	}
+	return nil, fmt.Errorf("policy template not found (name: %s)", name)
+}
+
 // FindInputByType returns the input for the provided type.
 func (pt *PolicyTemplate) FindInputByType(inputType string) *Input {
 	for _, input := range pt.Inputs {
@@ -0,0 +1,303 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// ExportProfile exports the managed files of the profile to the zip archive, together with their stored
+// default versions, used to upgrade the imported profile. Certificates aren't exported, they are generated
+// again when the profile is imported.
+func ExportProfile(profileName string, archivePath string) error {
+	loc, err := locations.NewLocationManager()
+	if err != nil {
+		return errors.Wrap(err, "error finding stack dir location")
+	}
+	return exportProfile(loc.ProfileDir(), profileName, archivePath)
+}
+
+// ImportProfile imports the profile from the zip archive created by ExportProfile. The profile name stored
+// in the archive is used, unless a new name is given. It returns the name of the imported profile.
+func ImportProfile(archivePath string, newName string) (string, error) {
+	loc, err := locations.NewLocationManager()
+	if err != nil {
+		return "", errors.Wrap(err, "error finding stack dir location")
+	}
+	return importProfile(loc.ProfileDir(), archivePath, newName)
+}
+
+func exportProfile(elasticPackagePath string, profileName string, archivePath string) error {
+	profile, err := loadProfile(elasticPackagePath, profileName)
+	if err != nil {
+		return errors.Wrapf(err, "error loading profile %s", profileName)
+	}
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return errors.Wrapf(err, "can't create archive file (path: %s)", archivePath)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, cfgName := range sortedConfigFiles(profile.configFiles) {
+		cfg := profile.configFiles[cfgName]
+		if _, err := os.Stat(cfg.path); os.IsNotExist(err) {
+			logger.Debugf("profile file not found, skipping (path: %s)", cfg.path)
+			continue
+		}
+
+		rel, err := filepath.Rel(profile.ProfilePath, cfg.path)
+		if err != nil {
+			return errors.Wrapf(err, "can't find relative path (path: %s)", cfg.path)
+		}
+
+		err = addArchiveFile(w, rel, []byte(cfg.body))
+		if err != nil {
+			return err
+		}
+
+		defaultBody, err := ioutil.ReadFile(profile.defaultFilePath(cfg.path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "can't read default version of profile file (path: %s)", rel)
+		}
+
+		err = addArchiveFile(w, filepath.Join(profileDefaultsPath, rel), defaultBody)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.Close()
+	if err != nil {
+		return errors.Wrap(err, "can't close archive")
+	}
+	return nil
+}
+
+func addArchiveFile(w *zip.Writer, rel string, body []byte) error {
+	logger.Debugf("Add %s to the profile archive", rel)
+	fw, err := w.Create(filepath.ToSlash(rel))
+	if err != nil {
+		return errors.Wrapf(err, "can't add file to archive (path: %s)", rel)
+	}
+	_, err = fw.Write(body)
+	if err != nil {
+		return errors.Wrapf(err, "can't write file to archive (path: %s)", rel)
+	}
+	return nil
+}
+
+func importProfile(elasticPackagePath string, archivePath string, newName string) (string, error) {
+	files, defaults, err := readProfileArchive(archivePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't read profile archive (path: %s)", archivePath)
+	}
+
+	metadata, err := validateProfileArchive(files)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid profile archive")
+	}
+
+	profileName := metadata.Name
+	if newName != "" {
+		profileName = newName
+	}
+	if profileName == "" {
+		return "", errors.New("profile name is not defined")
+	}
+	if filepath.Base(profileName) != profileName {
+		return "", fmt.Errorf("invalid profile name: %s", profileName)
+	}
+
+	profilePath := filepath.Join(elasticPackagePath, profileName)
+	if _, err := os.Stat(profilePath); err == nil {
+		return "", fmt.Errorf("profile %s already exists", profileName)
+	}
+
+	// Files are extracted to a temporary directory, so the profile can be created using existing files.
+	tempDir, err := ioutil.TempDir("", "elastic-package-profile-")
+	if err != nil {
+		return "", errors.Wrap(err, "can't create temporary directory")
+	}
+	defer os.RemoveAll(tempDir)
+
+	var paths []string
+	for cfgName, body := range files {
+		if cfgName == PackageProfileMetaFile {
+			continue
+		}
+		p := filepath.Join(tempDir, string(cfgName))
+		err = ioutil.WriteFile(p, body, 0644)
+		if err != nil {
+			return "", errors.Wrapf(err, "can't extract file (path: %s)", p)
+		}
+		paths = append(paths, p)
+	}
+
+	profile, err := newProfileFromExistingFiles(elasticPackagePath, profileName, paths, false)
+	if err != nil {
+		return "", errors.Wrap(err, "error creating new profile from files")
+	}
+
+	// Files missing in the archive (e.g. exported by an older version) get their default contents.
+	for cfgName, configInit := range managedProfileFiles {
+		if _, found := profile.configFiles[cfgName]; found {
+			continue
+		}
+		cfg, err := configInit(profileName, profilePath)
+		if err != nil {
+			return "", errors.Wrapf(err, "error initializing config %s", cfgName)
+		}
+		profile.configFiles[cfgName] = cfg
+	}
+
+	err = os.MkdirAll(profile.ProfileStackPath, 0755)
+	if err != nil {
+		return "", errors.Wrapf(err, "error crating profile directory %s", profile.ProfileStackPath)
+	}
+
+	err = profile.writeProfileResources()
+	if err != nil {
+		return "", errors.Wrap(err, "error writing profile")
+	}
+
+	// Default versions of imported files are known only if they're stored in the archive.
+	bases := map[configFile]*string{}
+	for cfgName := range files {
+		if defaultBody, found := defaults[cfgName]; found {
+			base := string(defaultBody)
+			bases[cfgName] = &base
+			continue
+		}
+		bases[cfgName] = nil
+	}
+
+	err = profile.writeDefaultFiles(bases)
+	if err != nil {
+		return "", errors.Wrap(err, "error writing default profile files")
+	}
+
+	err = profile.EnsureCertificates()
+	if err != nil {
+		return "", errors.Wrap(err, "error creating TLS certificates")
+	}
+	return profileName, nil
+}
+
+// readProfileArchive reads managed files and their default versions from the archive. Files which aren't
+// managed by profiles are rejected.
+func readProfileArchive(archivePath string) (map[configFile][]byte, map[configFile][]byte, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "can't open archive")
+	}
+	defer r.Close()
+
+	files := map[configFile][]byte{}
+	defaults := map[configFile][]byte{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		target := files
+		name := path.Clean(f.Name)
+		if strings.HasPrefix(name, profileDefaultsPath+"/") {
+			target = defaults
+			name = strings.TrimPrefix(name, profileDefaultsPath+"/")
+		}
+
+		cfgName, err := managedFileForArchivePath(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "can't open archived file (path: %s)", f.Name)
+		}
+		body, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "can't read archived file (path: %s)", f.Name)
+		}
+		target[cfgName] = body
+	}
+	return files, defaults, nil
+}
+
+// managedFileForArchivePath returns the managed file stored under the path in the archive.
+func managedFileForArchivePath(archivePath string) (configFile, error) {
+	for cfgName, configInit := range managedProfileFiles {
+		cfg, err := configInit("", "")
+		if err != nil {
+			return "", errors.Wrapf(err, "error initializing config %s", cfgName)
+		}
+		if filepath.ToSlash(cfg.path) == path.Clean(archivePath) {
+			return cfgName, nil
+		}
+	}
+	return "", fmt.Errorf("file %s is not managed by profiles", archivePath)
+}
+
+// validateProfileArchive checks if the profile was created by a compatible version of elastic-package
+// and if its configuration is valid.
+func validateProfileArchive(files map[configFile][]byte) (*Metadata, error) {
+	body, found := files[PackageProfileMetaFile]
+	if !found {
+		return nil, fmt.Errorf("profile metadata (%s) not found", PackageProfileMetaFile)
+	}
+
+	var metadata Metadata
+	err := json.Unmarshal(body, &metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling profile metadata")
+	}
+
+	formatVersion := metadata.FormatVersion
+	if formatVersion == 0 {
+		formatVersion = 1
+	}
+	if formatVersion > profileFormatVersion {
+		return nil, fmt.Errorf("profile created by incompatible version of elastic-package (version: %s, profile format: %d, supported format: %d)",
+			metadata.Version, formatVersion, profileFormatVersion)
+	}
+
+	if config, found := files[ProfileConfigFile]; found {
+		_, err = parseProfileConfig(string(config))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid profile configuration (%s)", ProfileConfigFile)
+		}
+	}
+	return &metadata, nil
+}
+
+func sortedConfigFiles(files map[configFile]*simpleFile) []configFile {
+	var names []configFile
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportProfile(t *testing.T) {
+	elasticPackageDir := t.TempDir()
+	require.NoError(t, createProfile(Options{PackagePath: elasticPackageDir, Name: "tuned"}))
+
+	tuned, err := loadProfile(elasticPackageDir, "tuned")
+	require.NoError(t, err)
+	tuned.configFiles[ProfileConfigFile].body = "stack:\n  elasticsearch:\n    nodes: 2\n"
+	require.NoError(t, tuned.writeProfileResources())
+
+	// Outdated files are upgraded also in imported profiles.
+	registryPath := tuned.FetchPath(PackageRegistryConfigFile)
+	require.NoError(t, writeFile(tuned.defaultFilePath(registryPath), "old: default\n"))
+	require.NoError(t, writeFile(registryPath, "old: default\n"))
+
+	archivePath := filepath.Join(t.TempDir(), "tuned.zip")
+	require.NoError(t, exportProfile(elasticPackageDir, "tuned", archivePath))
+
+	name, err := importProfile(elasticPackageDir, archivePath, "imported")
+	require.NoError(t, err)
+	require.Equal(t, "imported", name)
+
+	imported, err := loadProfile(elasticPackageDir, "imported")
+	require.NoError(t, err)
+	config, err := imported.Config()
+	require.NoError(t, err)
+	require.Equal(t, 2, config.Stack.Elasticsearch.Nodes)
+	require.FileExists(t, imported.FetchPath(SnapshotFile))
+	require.FileExists(t, imported.CACertificatePath())
+
+	diffs, err := diffProfiles(elasticPackageDir, "tuned", "imported")
+	require.NoError(t, err)
+	for _, d := range diffs {
+		require.Emptyf(t, d.Diff, "unexpected differences in %s", d.File)
+	}
+
+	require.Equal(t, FileOutdated, profileFileStatus(t, elasticPackageDir, "imported", PackageRegistryConfigFile))
+	require.Equal(t, FileCustomized, profileFileStatus(t, elasticPackageDir, "imported", ProfileConfigFile))
+
+	// Profiles can't be overwritten by imports.
+	_, err = importProfile(elasticPackageDir, archivePath, "imported")
+	require.Error(t, err)
+}
+
+func TestImportProfile_WithoutDefaults(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "legacy.zip")
+	writeTestArchive(t, archivePath, map[string]string{
+		"profile.json":            `{"name": "legacy"}`,
+		"stack/kibana.config.yml": "server.name: kibana\n",
+	})
+
+	elasticPackageDir := t.TempDir()
+	_, err := importProfile(elasticPackageDir, archivePath, "")
+	require.NoError(t, err)
+
+	require.Equal(t, FileModified, profileFileStatus(t, elasticPackageDir, "legacy", KibanaConfigFile))
+	require.Equal(t, FileUpToDate, profileFileStatus(t, elasticPackageDir, "legacy", SnapshotFile))
+}
+
+func TestImportProfile_IncompatibleVersion(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "future.zip")
+	writeTestArchive(t, archivePath, map[string]string{
+		"profile.json": `{"name": "future", "version": "abcdef", "format_version": 999}`,
+	})
+
+	_, err := importProfile(t.TempDir(), archivePath, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "incompatible version")
+}
+
+func TestImportProfile_UnmanagedFile(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "unmanaged.zip")
+	writeTestArchive(t, archivePath, map[string]string{
+		"profile.json":  `{"name": "unmanaged"}`,
+		"../escape.yml": "foo: bar",
+	})
+
+	_, err := importProfile(t.TempDir(), archivePath, "")
+	require.Error(t, err)
+}
+
+func writeTestArchive(t *testing.T, archivePath string, files map[string]string) {
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, body := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,207 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	profileCertsPath = "certs"
+
+	caCertificateFile = "ca-cert.pem"
+	caKeyFile         = "ca-key.pem"
+	certificateFile   = "cert.pem"
+	keyFile           = "key.pem"
+
+	certificateValidity = 10 * 365 * 24 * time.Hour
+	rsaKeySize          = 2048
+)
+
+// CertifiedServices are services of the Elastic stack, which get their own TLS certificates.
+var CertifiedServices = []string{"elasticsearch", "kibana", "fleet-server"}
+
+// CertificatesPath returns the path to the directory with the certificate authority and service certificates of the profile.
+func (profile Profile) CertificatesPath() string {
+	return filepath.Join(profile.ProfilePath, profileCertsPath)
+}
+
+// CACertificatePath returns the path to the certificate of the profile's certificate authority.
+func (profile Profile) CACertificatePath() string {
+	return filepath.Join(profile.CertificatesPath(), caCertificateFile)
+}
+
+// caKeyPath returns the path to the key of the profile's certificate authority. It's stored outside
+// of the certificates directory, as certificates are mounted into containers of the stack.
+func (profile Profile) caKeyPath() string {
+	return filepath.Join(profile.ProfilePath, caKeyFile)
+}
+
+// EnsureCertificates generates the certificate authority and certificates of Elastic stack services,
+// unless they already exist. Certificates are valid for the service name, localhost and 127.0.0.1.
+func (profile Profile) EnsureCertificates() error {
+	certsPath := profile.CertificatesPath()
+	caCertPath := profile.CACertificatePath()
+	caKeyPath := profile.caKeyPath()
+
+	_, err := os.Stat(caCertPath)
+	if err == nil {
+		return moveLegacyCAKey(filepath.Join(certsPath, caKeyFile), caKeyPath)
+	}
+	if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "stat file failed (path: %s)", caCertPath)
+	}
+
+	err = os.MkdirAll(certsPath, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "error creating certificates directory %s", certsPath)
+	}
+
+	ca, caKey, err := newCertificateAuthority()
+	if err != nil {
+		return errors.Wrap(err, "error creating certificate authority")
+	}
+
+	for _, service := range CertifiedServices {
+		cert, key, err := newServiceCertificate(service, ca, caKey)
+		if err != nil {
+			return errors.Wrapf(err, "error creating certificate for service %s", service)
+		}
+
+		servicePath := filepath.Join(certsPath, service)
+		err = os.MkdirAll(servicePath, 0755)
+		if err != nil {
+			return errors.Wrapf(err, "error creating certificates directory %s", servicePath)
+		}
+		// Keys of services are readable by all users, as services in containers run as different users.
+		err = writeCertificate(filepath.Join(servicePath, certificateFile), filepath.Join(servicePath, keyFile), 0644, cert, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The CA certificate is written last, as its existence indicates that all certificates are ready.
+	return writeCertificate(caCertPath, caKeyPath, 0600, ca, caKey)
+}
+
+// moveLegacyCAKey moves the key of the certificate authority, created by previous versions in the certificates
+// directory, to its current location.
+func moveLegacyCAKey(legacyPath, path string) error {
+	_, err := os.Stat(legacyPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "stat file failed (path: %s)", legacyPath)
+	}
+
+	err = os.Chmod(legacyPath, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "error changing permissions of %s", legacyPath)
+	}
+	err = os.Rename(legacyPath, path)
+	if err != nil {
+		return errors.Wrapf(err, "error moving key %s", legacyPath)
+	}
+	return nil
+}
+
+func newCertificateAuthority() (*x509.Certificate, *rsa.PrivateKey, error) {
+	template, err := certificateTemplate("elastic-package CA")
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	template.BasicConstraintsValid = true
+
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error generating key")
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating certificate")
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error parsing certificate")
+	}
+	return cert, key, nil
+}
+
+func newServiceCertificate(service string, ca *x509.Certificate, caKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey, error) {
+	template, err := certificateTemplate(service)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	template.DNSNames = []string{service, "localhost"}
+	template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error generating key")
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating certificate")
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error parsing certificate")
+	}
+	return cert, key, nil
+}
+
+func certificateTemplate(commonName string) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating serial number")
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{"elastic-package"},
+		},
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(certificateValidity),
+	}, nil
+}
+
+func writeCertificate(certPath, keyPath string, keyPerm os.FileMode, cert *x509.Certificate, key *rsa.PrivateKey) error {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	err := ioutil.WriteFile(certPath, certPEM, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "error writing certificate %s", certPath)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	err = ioutil.WriteFile(keyPath, keyPEM, keyPerm)
+	if err != nil {
+		return errors.Wrapf(err, "error writing key %s", keyPath)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureCertificates(t *testing.T) {
+	profile := Profile{ProfilePath: t.TempDir()}
+
+	require.NoError(t, profile.EnsureCertificates())
+
+	caCert, err := ioutil.ReadFile(profile.CACertificatePath())
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caCert))
+
+	for _, service := range CertifiedServices {
+		servicePath := filepath.Join(profile.CertificatesPath(), service)
+		pair, err := tls.LoadX509KeyPair(filepath.Join(servicePath, certificateFile), filepath.Join(servicePath, keyFile))
+		require.NoError(t, err)
+
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		require.NoError(t, err)
+		for _, name := range []string{service, "localhost", "127.0.0.1"} {
+			_, err = cert.Verify(x509.VerifyOptions{DNSName: name, Roots: roots})
+			require.NoErrorf(t, err, "certificate of %s not valid for %s", service, name)
+		}
+	}
+
+	// The key of the certificate authority isn't stored with certificates mounted into containers.
+	_, err = os.Stat(filepath.Join(profile.CertificatesPath(), caKeyFile))
+	require.True(t, os.IsNotExist(err))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(profile.caKeyPath())
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// Existing certificates are kept.
+	require.NoError(t, profile.EnsureCertificates())
+	current, err := ioutil.ReadFile(profile.CACertificatePath())
+	require.NoError(t, err)
+	require.Equal(t, caCert, current)
+}
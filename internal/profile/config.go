@@ -0,0 +1,223 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileConfigFile is the configuration file of the profile, used to customize stack services.
+const ProfileConfigFile configFile = "config.yml"
+
+const profileConfigYml = `# Configuration of the Elastic stack booted up using this profile.
+# All settings are optional, uncomment them to customize the stack.
+#stack:
+#  tls:
+#    # Serve Elasticsearch, Kibana and Fleet Server over HTTPS, using certificates signed by
+#    # the certificate authority of the profile (see the "certs" directory).
+#    enabled: true
+#  elasticsearch:
+#    # Heap size of every Elasticsearch node.
+#    heap_size: 1g
+#    # Number of Elasticsearch nodes. Additional nodes are named elasticsearch-2, elasticsearch-3, etc.
+#    # Multi-node clusters run in production mode, which requires vm.max_map_count >= 262144 on the Docker host.
+#    nodes: 1
+#    # Additional Elasticsearch settings.
+#    settings:
+#      indices.query.bool.max_clause_count: 4096
+#  kibana:
+#    # Additional Kibana settings, overriding settings of kibana.config.yml.
+#    settings:
+#      logging.verbose: true
+#  elastic_agent:
+#    # Number of Elastic Agents. Additional agents are named elastic-agent-2, elastic-agent-3, etc.
+#    replicas: 1
+#  # Additional side-car services (Docker Compose service definitions), started in the stack network.
+#  services:
+#    logstash:
+#      image: docker.elastic.co/logstash/logstash:7.15.0
+#      depends_on:
+#        elasticsearch:
+#          condition: service_healthy
+#      ports:
+#        - "127.0.0.1:5044:5044"
+`
+
+// remoteProfileConfigYml is the configuration of profiles targeting a remote stack.
+const remoteProfileConfigYml = `# Configuration of the remote Elastic stack (e.g. an Elastic Cloud deployment) targeted by this profile.
+# Stack services aren't booted up locally, except the Elastic Agent used by system tests, which is enrolled
+# into the remote Fleet.
+type: remote
+remote:
+  # URLs of Elasticsearch, Kibana and Fleet Server.
+  elasticsearch_url: ""
+  kibana_url: ""
+  fleet_server_url: ""
+  # API key used to authenticate in Elasticsearch and Kibana: base64-encoded "id:api_key"
+  # (the "encoded" value returned by the create API key API).
+  api_key: ""
+  # Alternatively, username and password can be used.
+  #username: elastic
+  #password: changeme
+  # Path to the certificate authority used to verify HTTPS endpoints, if not trusted by the system.
+  #ca_cert: /path/to/ca.pem
+`
+
+// Types of profiles.
+const (
+	ProfileTypeLocal  = "local"
+	ProfileTypeRemote = "remote"
+)
+
+// Default values of stack settings.
+const (
+	DefaultElasticsearchHeapSize = "1g"
+	DefaultElasticsearchNodes    = 1
+	DefaultElasticAgentReplicas  = 1
+)
+
+var serviceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Config defines the configuration of the profile.
+type Config struct {
+	// Type of the profile: "local" (default) boots up the stack locally, "remote" targets an existing deployment.
+	Type string `yaml:"type"`
+
+	Stack  StackConfig  `yaml:"stack"`
+	Remote RemoteConfig `yaml:"remote"`
+}
+
+// RemoteConfig defines endpoints and credentials of the remote Elastic stack.
+type RemoteConfig struct {
+	ElasticsearchURL string `yaml:"elasticsearch_url"`
+	KibanaURL        string `yaml:"kibana_url"`
+	FleetServerURL   string `yaml:"fleet_server_url"`
+
+	APIKey   string `yaml:"api_key"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	CACert string `yaml:"ca_cert"`
+}
+
+// IsRemote returns true if the profile targets a remote Elastic stack.
+func (c Config) IsRemote() bool {
+	return c.Type == ProfileTypeRemote
+}
+
+// StackConfig defines customizations of Elastic stack services.
+type StackConfig struct {
+	TLS           TLSConfig           `yaml:"tls"`
+	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch"`
+	Kibana        KibanaConfig        `yaml:"kibana"`
+	ElasticAgent  ElasticAgentConfig  `yaml:"elastic_agent"`
+
+	// Services defines additional side-car services as Docker Compose service definitions.
+	Services map[string]map[string]interface{} `yaml:"services"`
+}
+
+// TLSConfig defines whether stack services are served over HTTPS.
+type TLSConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// ElasticsearchConfig defines customizations of Elasticsearch nodes.
+type ElasticsearchConfig struct {
+	HeapSize string                 `yaml:"heap_size"`
+	Nodes    int                    `yaml:"nodes"`
+	Settings map[string]interface{} `yaml:"settings"`
+}
+
+// KibanaConfig defines customizations of Kibana.
+type KibanaConfig struct {
+	Settings map[string]interface{} `yaml:"settings"`
+}
+
+// ElasticAgentConfig defines customizations of Elastic Agents.
+type ElasticAgentConfig struct {
+	Replicas int `yaml:"replicas"`
+}
+
+// newProfileConfig returns a Managed Config
+func newProfileConfig(_ string, profilePath string) (*simpleFile, error) {
+	return &simpleFile{
+		name: string(ProfileConfigFile),
+		path: filepath.Join(profilePath, string(ProfileConfigFile)),
+		body: profileConfigYml,
+	}, nil
+}
+
+// Config returns the configuration of the profile with default values applied.
+func (profile Profile) Config() (*Config, error) {
+	cfgFile, found := profile.configFiles[ProfileConfigFile]
+	if !found {
+		return parseProfileConfig("")
+	}
+
+	config, err := parseProfileConfig(cfgFile.body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid profile configuration (path: %s)", cfgFile.path)
+	}
+	return config, nil
+}
+
+func parseProfileConfig(body string) (*Config, error) {
+	var config Config
+	err := yaml.Unmarshal([]byte(body), &config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling profile configuration failed")
+	}
+
+	if config.Type == "" {
+		config.Type = ProfileTypeLocal
+	}
+	if config.Stack.Elasticsearch.HeapSize == "" {
+		config.Stack.Elasticsearch.HeapSize = DefaultElasticsearchHeapSize
+	}
+	if config.Stack.Elasticsearch.Nodes == 0 {
+		config.Stack.Elasticsearch.Nodes = DefaultElasticsearchNodes
+	}
+	if config.Stack.ElasticAgent.Replicas == 0 {
+		config.Stack.ElasticAgent.Replicas = DefaultElasticAgentReplicas
+	}
+
+	err = config.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (c Config) validate() error {
+	switch c.Type {
+	case ProfileTypeLocal:
+	case ProfileTypeRemote:
+		if c.Remote.ElasticsearchURL == "" || c.Remote.KibanaURL == "" {
+			return errors.New("remote profile requires URLs of Elasticsearch and Kibana (remote.elasticsearch_url, remote.kibana_url)")
+		}
+		if c.Remote.APIKey == "" && c.Remote.Username == "" {
+			return errors.New("remote profile requires credentials (remote.api_key or remote.username and remote.password)")
+		}
+	default:
+		return fmt.Errorf("unsupported profile type: %s", c.Type)
+	}
+	if c.Stack.Elasticsearch.Nodes < 1 {
+		return fmt.Errorf("number of Elasticsearch nodes must be positive (nodes: %d)", c.Stack.Elasticsearch.Nodes)
+	}
+	if c.Stack.ElasticAgent.Replicas < 1 {
+		return fmt.Errorf("number of Elastic Agents must be positive (replicas: %d)", c.Stack.ElasticAgent.Replicas)
+	}
+	for name := range c.Stack.Services {
+		if !serviceNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid name of the side-car service: %q", name)
+		}
+	}
+	return nil
+}
@@ -81,3 +81,65 @@ func cleanupProfile(t *testing.T, dir string) {
 	err := os.RemoveAll(dir)
 	assert.NoErrorf(t, err, "Error cleaning up tempdir %s", dir)
 }
+
+func TestParseProfileConfig(t *testing.T) {
+	config, err := parseProfileConfig(profileConfigYml)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultElasticsearchHeapSize, config.Stack.Elasticsearch.HeapSize)
+	assert.Equal(t, DefaultElasticsearchNodes, config.Stack.Elasticsearch.Nodes)
+	assert.Equal(t, DefaultElasticAgentReplicas, config.Stack.ElasticAgent.Replicas)
+
+	config, err = parseProfileConfig(`
+stack:
+  elasticsearch:
+    nodes: 3
+  services:
+    logstash:
+      image: logstash
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, config.Stack.Elasticsearch.Nodes)
+	assert.Contains(t, config.Stack.Services, "logstash")
+
+	_, err = parseProfileConfig(`
+stack:
+  elastic_agent:
+    replicas: -1
+`)
+	assert.Error(t, err)
+
+	_, err = parseProfileConfig(`
+stack:
+  services:
+    Invalid Name:
+      image: logstash
+`)
+	assert.Error(t, err)
+}
+
+func TestParseProfileConfig_Remote(t *testing.T) {
+	_, err := parseProfileConfig(remoteProfileConfigYml)
+	assert.Error(t, err, "remote profile without URLs should be invalid")
+
+	config, err := parseProfileConfig(`
+type: remote
+remote:
+  elasticsearch_url: https://elasticsearch.example.com:9243
+  kibana_url: https://kibana.example.com:9243
+  api_key: Zm9vOmJhcg==
+`)
+	assert.NoError(t, err)
+	assert.True(t, config.IsRemote())
+	assert.Equal(t, "Zm9vOmJhcg==", config.Remote.APIKey)
+
+	_, err = parseProfileConfig(`
+type: remote
+remote:
+  elasticsearch_url: https://elasticsearch.example.com:9243
+  kibana_url: https://kibana.example.com:9243
+`)
+	assert.Error(t, err, "remote profile without credentials should be invalid")
+
+	_, err = parseProfileConfig("type: cloud\n")
+	assert.Error(t, err)
+}
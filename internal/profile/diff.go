@@ -0,0 +1,57 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"github.com/kylelemons/godebug/diff"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+)
+
+// FileDiff describes differences of the managed file between two profiles.
+type FileDiff struct {
+	File string
+
+	// Diff contains differences in a line-by-line format, empty if files are equal.
+	Diff string
+}
+
+// DiffProfiles compares managed files of two profiles. Profile metadata isn't compared.
+func DiffProfiles(profileA, profileB string) ([]FileDiff, error) {
+	loc, err := locations.NewLocationManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding stack dir location")
+	}
+	return diffProfiles(loc.ProfileDir(), profileA, profileB)
+}
+
+func diffProfiles(elasticPackagePath string, profileA, profileB string) ([]FileDiff, error) {
+	a, err := loadProfile(elasticPackagePath, profileA)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading profile %s", profileA)
+	}
+
+	b, err := loadProfile(elasticPackagePath, profileB)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading profile %s", profileB)
+	}
+
+	var diffs []FileDiff
+	for _, cfgName := range sortedConfigFiles(a.configFiles) {
+		if cfgName == PackageProfileMetaFile {
+			continue
+		}
+
+		fileDiff := FileDiff{File: string(cfgName)}
+		bodyA := a.configFiles[cfgName].body
+		bodyB := b.configFiles[cfgName].body
+		if bodyA != bodyB {
+			fileDiff.Diff = diff.Diff(bodyA, bodyB)
+		}
+		diffs = append(diffs, fileDiff)
+	}
+	return diffs, nil
+}
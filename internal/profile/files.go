@@ -6,6 +6,7 @@ package profile
 
 import (
 	"io/ioutil"
+	"os"
 
 	"github.com/pkg/errors"
 )
@@ -24,8 +25,12 @@ type simpleFile struct {
 const profileStackPath = "stack"
 
 // configfilesDiffer checks to see if a local configItem differs from the one it knows.
+// A missing local file is treated as a difference.
 func (cfg simpleFile) configfilesDiffer() (bool, error) {
 	changes, err := ioutil.ReadFile(cfg.path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
 	if err != nil {
 		return false, errors.Wrapf(err, "error reading %s", KibanaConfigFile)
 	}
@@ -33,6 +33,9 @@ func CreateProfile(options Options) error {
 	if options.FromProfile == "" || options.FromProfile == DefaultProfile {
 		return createProfile(options)
 	}
+	if options.Type != "" {
+		return errors.New("profile type can't be selected when copying an existing profile")
+	}
 
 	return createProfileFrom(options)
 }
@@ -48,6 +51,10 @@ func MigrateProfileFiles(options Options, files []string) error {
 	if err != nil {
 		return errors.Wrapf(err, "error crating profile directory %s", profile.ProfilePath)
 	}
+	err = os.Mkdir(profile.ProfileStackPath, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "error crating profile directory %s", profile.ProfileStackPath)
+	}
 	err = profile.writeProfileResources()
 	if err != nil {
 		return errors.Wrap(err, "error writing out new profile config")
@@ -107,15 +114,34 @@ func FetchAllProfiles(elasticPackagePath string) ([]Metadata, error) {
 // overwriteExisting determines the behavior if a profile with the given name already exists.
 // On true, it'll overwrite the profile, on false, it'll backup the existing profile to profilename_VERSION-DATE-CREATED
 func createProfile(options Options) error {
+	if options.Type != "" && options.Type != ProfileTypeLocal && options.Type != ProfileTypeRemote {
+		return fmt.Errorf("unsupported profile type: %s", options.Type)
+	}
+
 	profile, err := createAndCheckProfile(options.PackagePath, options.Name, options.OverwriteExisting)
 	if err != nil {
 		return errors.Wrap(err, "error creating new profile")
 	}
+
+	if options.Type == ProfileTypeRemote {
+		profile.configFiles[ProfileConfigFile].body = remoteProfileConfigYml
+	}
+
 	// write the resources
 	err = profile.writeProfileResources()
 	if err != nil {
 		return errors.Wrap(err, "error writing profile file")
 	}
+
+	err = profile.writeDefaultFiles(nil)
+	if err != nil {
+		return errors.Wrap(err, "error writing default profile files")
+	}
+
+	err = profile.EnsureCertificates()
+	if err != nil {
+		return errors.Wrap(err, "error creating TLS certificates")
+	}
 	return nil
 }
 
@@ -131,11 +157,27 @@ func createProfileFrom(options Options) error {
 		return errors.Wrap(err, "error creating new profile")
 	}
 
+	// Defaults used to create the source profile are the base of copied files, as they may be outdated.
+	bases, err := fromProfile.readDefaultFiles()
+	if err != nil {
+		return errors.Wrapf(err, "error reading default files of %s profile", options.FromProfile)
+	}
+
 	newProfile.overwrite(fromProfile.configFiles)
 	err = newProfile.writeProfileResources()
 	if err != nil {
 		return errors.Wrap(err, "error writing new profile")
 	}
+
+	err = newProfile.writeDefaultFiles(bases)
+	if err != nil {
+		return errors.Wrap(err, "error writing default profile files")
+	}
+
+	err = newProfile.EnsureCertificates()
+	if err != nil {
+		return errors.Wrap(err, "error creating TLS certificates")
+	}
 	return nil
 }
 
@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kylelemons/godebug/diff"
+)
+
+// hunk replaces lines [start, end) of the base version with new lines.
+type hunk struct {
+	start, end int
+	lines      []string
+	side       int
+}
+
+// mergeThreeWay merges changes introduced to the base version by both versions. Changes touching
+// the same or adjacent lines conflict, unless they are identical. It returns false if there are conflicts.
+func mergeThreeWay(base, ours, theirs string) (string, bool) {
+	baseLines := splitLines(base)
+
+	var hunks []hunk
+	hunks = append(hunks, changeHunks(baseLines, splitLines(ours), 0)...)
+	hunks = append(hunks, changeHunks(baseLines, splitLines(theirs), 1)...)
+	sort.SliceStable(hunks, func(i, j int) bool {
+		return hunks[i].start < hunks[j].start
+	})
+
+	var merged []string
+	pos := 0
+	for i := 0; i < len(hunks); {
+		// Group hunks touching each other.
+		start, end := hunks[i].start, hunks[i].end
+		j := i + 1
+		for j < len(hunks) && hunks[j].start <= end {
+			if hunks[j].end > end {
+				end = hunks[j].end
+			}
+			j++
+		}
+		group := hunks[i:j]
+		i = j
+
+		merged = append(merged, baseLines[pos:start]...)
+		pos = end
+
+		oursLines, oursChanged := applyHunks(baseLines, start, end, group, 0)
+		theirsLines, theirsChanged := applyHunks(baseLines, start, end, group, 1)
+		switch {
+		case !theirsChanged:
+			merged = append(merged, oursLines...)
+		case !oursChanged:
+			merged = append(merged, theirsLines...)
+		case strings.Join(oursLines, "") == strings.Join(theirsLines, ""):
+			merged = append(merged, oursLines...)
+		default:
+			return "", false
+		}
+	}
+	merged = append(merged, baseLines[pos:]...)
+	return strings.Join(merged, ""), true
+}
+
+// changeHunks returns changes between the base and the changed version.
+func changeHunks(base, changed []string, side int) []hunk {
+	var hunks []hunk
+	pos := 0
+	for _, c := range diff.DiffChunks(base, changed) {
+		if len(c.Added) > 0 || len(c.Deleted) > 0 {
+			hunks = append(hunks, hunk{
+				start: pos,
+				end:   pos + len(c.Deleted),
+				lines: c.Added,
+				side:  side,
+			})
+		}
+		pos += len(c.Deleted) + len(c.Equal)
+	}
+	return hunks
+}
+
+// applyHunks applies hunks of the side to base lines [start, end).
+func applyHunks(base []string, start, end int, hunks []hunk, side int) ([]string, bool) {
+	var result []string
+	changed := false
+	pos := start
+	for _, h := range hunks {
+		if h.side != side {
+			continue
+		}
+		changed = true
+		result = append(result, base[pos:h.start]...)
+		result = append(result, h.lines...)
+		pos = h.end
+	}
+	result = append(result, base[pos:end]...)
+	return result, changed
+}
+
+func splitLines(body string) []string {
+	if body == "" {
+		return nil
+	}
+	return strings.SplitAfter(body, "\n")
+}
@@ -9,5 +9,6 @@ type Options struct {
 	PackagePath       string
 	Name              string
 	FromProfile       string
+	Type              string
 	OverwriteExisting bool
 }
@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 
 	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/logger"
 )
 
 // Profile manages a a given user config profile
@@ -41,6 +43,7 @@ var managedProfileFiles = map[configFile]NewConfig{
 	PackageRegistryDockerfileFile: newPackageRegistryDockerfile,
 	PackageRegistryConfigFile:     newPackageRegistryConfig,
 	SnapshotFile:                  newSnapshotFile,
+	ProfileConfigFile:             newProfileConfig,
 	PackageProfileMetaFile:        createProfileMetadata,
 }
 
@@ -69,6 +72,7 @@ func NewConfigProfile(elasticPackagePath string, profileName string) (*Profile,
 // newProfileFromExistingFiles creates a profile from a list of absolute filepaths
 // This can be used when migrating a config from a non-profiles-managed config set
 // ignoreMissing will treat non-existant files as soft errors
+// Managed files are placed in their usual locations within the profile.
 func newProfileFromExistingFiles(elasticPackagePath string, profileName string, files []string, ignoreMissing bool) (*Profile, error) {
 	profilePath := filepath.Join(elasticPackagePath, profileName)
 	var configMap = map[configFile]*simpleFile{}
@@ -88,9 +92,17 @@ func newProfileFromExistingFiles(elasticPackagePath string, profileName string,
 		}
 		//format this in the way configFile expects
 		name := filepath.Base(file)
+		path := filepath.Join(profilePath, name)
+		if configInit, ok := managedProfileFiles[configFile(name)]; ok {
+			cfg, err := configInit(profileName, profilePath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error initializing config %s", name)
+			}
+			path = cfg.path
+		}
 		configMap[configFile(name)] = &simpleFile{
 			name: name,
-			path: filepath.Join(profilePath, name),
+			path: path,
 			body: string(byteFile),
 		}
 	}
@@ -103,9 +115,10 @@ func newProfileFromExistingFiles(elasticPackagePath string, profileName string,
 	configMap[PackageProfileMetaFile] = metadata
 
 	newProfile := &Profile{
-		profileName: profileName,
-		ProfilePath: profilePath,
-		configFiles: configMap,
+		profileName:      profileName,
+		ProfilePath:      profilePath,
+		ProfileStackPath: filepath.Join(profilePath, profileStackPath),
+		configFiles:      configMap,
 	}
 	return newProfile, nil
 }
@@ -157,6 +170,11 @@ func loadProfile(elasticPackagePath string, profileName string) (*Profile, error
 
 }
 
+// Name returns the name of the profile.
+func (profile Profile) Name() string {
+	return profile.profileName
+}
+
 // FetchPath returns an absolute path to the given file
 func (profile Profile) FetchPath(file configFile) string {
 	return profile.configFiles[file].path
@@ -253,8 +271,14 @@ func (profile Profile) localFilesChanged() (bool, error) {
 }
 
 // readProfileResources reads the associated files into the config, as opposed to writing them out.
+// Files missing in profiles created by older versions of elastic-package keep their default contents.
 func (profile Profile) readProfileResources() error {
 	for _, cfgFile := range profile.configFiles {
+		if _, err := os.Stat(cfgFile.path); os.IsNotExist(err) {
+			logger.Debugf("profile file not found, using defaults (path: %s)", cfgFile.path)
+			continue
+		}
+
 		err := cfgFile.readConfig()
 		if err != nil {
 			return errors.Wrap(err, "error reading in profile")
@@ -17,13 +17,19 @@ import (
 
 // Metadata stores the data associated with a given profile
 type Metadata struct {
-	Name        string    `json:"name"`
-	DateCreated time.Time `json:"date_created"`
-	User        string    `json:"user"`
-	Version     string    `json:"version"`
-	Path        string    `json:"path"`
+	Name          string    `json:"name"`
+	DateCreated   time.Time `json:"date_created"`
+	User          string    `json:"user"`
+	Version       string    `json:"version"`
+	Path          string    `json:"path"`
+	FormatVersion int       `json:"format_version,omitempty"`
 }
 
+// profileFormatVersion is the version of the profile layout. It must be increased whenever profiles
+// created by older versions of elastic-package can't be used anymore (e.g. files are moved).
+// Profiles without the format version in metadata use the first version.
+const profileFormatVersion = 1
+
 // PackageProfileMetaFile is the filename of the profile metadata file
 const PackageProfileMetaFile configFile = "profile.json"
 
@@ -35,11 +41,12 @@ func createProfileMetadata(profileName string, profilePath string) (*simpleFile,
 	}
 
 	profileData := Metadata{
-		profileName,
-		time.Now(),
-		currentUser.Username,
-		version.CommitHash,
-		profilePath,
+		Name:          profileName,
+		DateCreated:   time.Now(),
+		User:          currentUser.Username,
+		Version:       version.CommitHash,
+		Path:          profilePath,
+		FormatVersion: profileFormatVersion,
 	}
 
 	jsonRaw, err := json.MarshalIndent(profileData, "", "  ")
@@ -0,0 +1,303 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kylelemons/godebug/diff"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// profileDefaultsPath is the directory storing default versions of managed files, which were used to create
+// the profile. They're used as the common base to merge changes of defaults with changes of the user.
+const profileDefaultsPath = "defaults"
+
+// FileStatus describes the state of the managed file compared to the current default.
+type FileStatus string
+
+// Possible states of managed files.
+const (
+	// FileUpToDate means that the file is equal to the current default.
+	FileUpToDate FileStatus = "up-to-date"
+	// FileMissing means that the file doesn't exist in the profile (e.g. it was added in a newer version).
+	FileMissing FileStatus = "missing"
+	// FileCustomized means that the file has been changed by the user, but the default hasn't changed.
+	FileCustomized FileStatus = "customized"
+	// FileOutdated means that the default has changed, but the file hasn't been changed by the user.
+	FileOutdated FileStatus = "outdated"
+	// FileMergeable means that both the default and the file have changed, but changes don't conflict.
+	FileMergeable FileStatus = "mergeable"
+	// FileConflict means that both the default and the file have changed, and changes conflict.
+	FileConflict FileStatus = "conflict"
+	// FileModified means that the file differs from the current default, but the default used
+	// to create the profile is unknown.
+	FileModified FileStatus = "modified"
+)
+
+// Upgradable returns true if the file can be upgraded automatically.
+func (s FileStatus) Upgradable() bool {
+	return s == FileMissing || s == FileOutdated || s == FileMergeable
+}
+
+// FileCheck describes the managed file of the profile compared to the current default.
+type FileCheck struct {
+	File   string
+	Status FileStatus
+
+	// OldDefault is the default used to create the profile, empty if unknown.
+	OldDefault    string
+	HasOldDefault bool
+	NewDefault    string
+	Current       string
+
+	// Merged is the upgraded version of the file.
+	Merged string
+
+	path        string
+	defaultPath string
+}
+
+// ThreeWayView returns differences between the old default and both the new default and the current version.
+func (fc FileCheck) ThreeWayView() string {
+	if !fc.HasOldDefault {
+		return fmt.Sprintf("--- current default -> %s (previous default unknown)\n%s", fc.File, diff.Diff(fc.NewDefault, fc.Current))
+	}
+	return fmt.Sprintf("--- old default -> new default\n%s\n--- old default -> %s\n%s",
+		diff.Diff(fc.OldDefault, fc.NewDefault), fc.File, diff.Diff(fc.OldDefault, fc.Current))
+}
+
+// CheckProfile compares managed files of the profile with current defaults.
+func CheckProfile(profileName string) ([]FileCheck, error) {
+	loc, err := locations.NewLocationManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding stack dir location")
+	}
+	return checkProfile(loc.ProfileDir(), profileName)
+}
+
+// UpgradeProfile updates managed files of the profile to current defaults, merging non-conflicting changes
+// of the user. Conflicting files are kept untouched. Modified files, whose previous default is unknown, are
+// overwritten with current defaults only if requested. It returns results of the check done before the upgrade.
+func UpgradeProfile(profileName string, overwriteModified bool) ([]FileCheck, error) {
+	loc, err := locations.NewLocationManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "error finding stack dir location")
+	}
+	return upgradeProfile(loc.ProfileDir(), profileName, overwriteModified)
+}
+
+func checkProfile(elasticPackagePath string, profileName string) ([]FileCheck, error) {
+	current, err := loadProfile(elasticPackagePath, profileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading profile %s", profileName)
+	}
+
+	defaults, err := NewConfigProfile(elasticPackagePath, profileName)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating default profile")
+	}
+
+	var checks []FileCheck
+	for _, cfgName := range sortedConfigFiles(defaults.configFiles) {
+		if cfgName == PackageProfileMetaFile {
+			continue
+		}
+
+		check, err := checkProfileFile(current, defaults.configFiles[cfgName])
+		if err != nil {
+			return nil, errors.Wrapf(err, "error checking file %s", cfgName)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+func checkProfileFile(current *Profile, newDefault *simpleFile) (FileCheck, error) {
+	check := FileCheck{
+		File:        newDefault.name,
+		NewDefault:  newDefault.body,
+		path:        newDefault.path,
+		defaultPath: current.defaultFilePath(newDefault.path),
+	}
+
+	differ, err := newDefault.configfilesDiffer()
+	if err != nil {
+		return check, err
+	}
+	if !differ {
+		check.Status = FileUpToDate
+		check.Current = newDefault.body
+		return check, nil
+	}
+
+	body, err := ioutil.ReadFile(newDefault.path)
+	if os.IsNotExist(err) {
+		check.Status = FileMissing
+		check.Merged = newDefault.body
+		return check, nil
+	}
+	if err != nil {
+		return check, errors.Wrapf(err, "error reading %s", newDefault.path)
+	}
+	check.Current = string(body)
+
+	oldDefault, err := ioutil.ReadFile(check.defaultPath)
+	if os.IsNotExist(err) {
+		check.Status = FileModified
+		return check, nil
+	}
+	if err != nil {
+		return check, errors.Wrapf(err, "error reading %s", check.defaultPath)
+	}
+	check.OldDefault = string(oldDefault)
+	check.HasOldDefault = true
+
+	switch {
+	case check.OldDefault == check.NewDefault:
+		check.Status = FileCustomized
+	case check.OldDefault == check.Current:
+		check.Status = FileOutdated
+		check.Merged = check.NewDefault
+	default:
+		merged, ok := mergeThreeWay(check.OldDefault, check.NewDefault, check.Current)
+		if !ok {
+			check.Status = FileConflict
+			break
+		}
+		check.Status = FileMergeable
+		check.Merged = merged
+	}
+	return check, nil
+}
+
+func upgradeProfile(elasticPackagePath string, profileName string, overwriteModified bool) ([]FileCheck, error) {
+	checks, err := checkProfile(elasticPackagePath, profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, check := range checks {
+		overwrite := overwriteModified && check.Status == FileModified
+		if overwrite {
+			logger.Debugf("Overwrite profile file %s with current default (status: %s)", check.File, check.Status)
+			err = writeFile(check.path, check.NewDefault)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if check.Status.Upgradable() {
+			logger.Debugf("Upgrade profile file %s (status: %s)", check.File, check.Status)
+			err = writeFile(check.path, check.Merged)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The new default becomes the base of future upgrades, unless the file couldn't be upgraded.
+		if check.Status != FileConflict && (check.Status != FileModified || overwrite) {
+			err = writeFile(check.defaultPath, check.NewDefault)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return checks, nil
+}
+
+// writeDefaultFiles stores default versions of managed files, used later to upgrade the profile.
+// Files copied from another profile keep the default version stored there (bases). If it's unknown (nil),
+// no default is stored, so the file isn't mistaken for a customization of the current default.
+// Remaining files get current defaults.
+func (profile Profile) writeDefaultFiles(bases map[configFile]*string) error {
+	defaults, err := NewConfigProfile(filepath.Dir(profile.ProfilePath), profile.profileName)
+	if err != nil {
+		return errors.Wrap(err, "error creating default profile")
+	}
+
+	for cfgName, cfg := range defaults.configFiles {
+		if cfgName == PackageProfileMetaFile {
+			continue
+		}
+
+		defaultPath := profile.defaultFilePath(cfg.path)
+		body := cfg.body
+		if base, found := bases[cfgName]; found {
+			if base == nil {
+				logger.Debugf("Default version of profile file %s is unknown", cfgName)
+				err = os.Remove(defaultPath)
+				if err != nil && !os.IsNotExist(err) {
+					return errors.Wrapf(err, "error removing %s", defaultPath)
+				}
+				continue
+			}
+			body = *base
+		}
+
+		err = writeFile(defaultPath, body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDefaultFiles returns default versions stored for managed files existing in the profile. Files without
+// a stored default version (e.g. created by older versions of elastic-package) are mapped to nil.
+func (profile Profile) readDefaultFiles() (map[configFile]*string, error) {
+	bases := map[configFile]*string{}
+	for cfgName, cfg := range profile.configFiles {
+		if cfgName == PackageProfileMetaFile {
+			continue
+		}
+
+		_, err := os.Stat(cfg.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "stat failed (path: %s)", cfg.path)
+		}
+
+		defaultPath := profile.defaultFilePath(cfg.path)
+		body, err := ioutil.ReadFile(defaultPath)
+		if os.IsNotExist(err) {
+			bases[cfgName] = nil
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %s", defaultPath)
+		}
+		base := string(body)
+		bases[cfgName] = &base
+	}
+	return bases, nil
+}
+
+// defaultFilePath returns the path to the stored default version of the managed file.
+func (profile Profile) defaultFilePath(path string) string {
+	rel := strings.TrimPrefix(path, profile.ProfilePath+string(filepath.Separator))
+	return filepath.Join(profile.ProfilePath, profileDefaultsPath, rel)
+}
+
+func writeFile(path, body string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return errors.Wrapf(err, "error creating directory %s", filepath.Dir(path))
+	}
+	err = ioutil.WriteFile(path, []byte(body), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "writing file failed (path: %s)", path)
+	}
+	return nil
+}
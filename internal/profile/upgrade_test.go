@@ -0,0 +1,147 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeThreeWay(t *testing.T) {
+	base := "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\n"
+
+	merged, ok := mergeThreeWay(base, "a: 1\nb: 20\nc: 3\nd: 4\ne: 5\n", "a: 1\nb: 2\nc: 3\nd: 4\ne: 50\nf: 6\n")
+	require.True(t, ok)
+	require.Equal(t, "a: 1\nb: 20\nc: 3\nd: 4\ne: 50\nf: 6\n", merged)
+
+	merged, ok = mergeThreeWay(base, "a: 1\nb: 20\nc: 3\nd: 4\ne: 5\n", "a: 1\nb: 20\nc: 3\nd: 4\ne: 5\n")
+	require.True(t, ok)
+	require.Equal(t, "a: 1\nb: 20\nc: 3\nd: 4\ne: 5\n", merged)
+
+	_, ok = mergeThreeWay(base, "a: 1\nb: 20\nc: 3\nd: 4\ne: 5\n", "a: 1\nb: 21\nc: 3\nd: 4\ne: 5\n")
+	require.False(t, ok)
+}
+
+func TestUpgradeProfile(t *testing.T) {
+	elasticPackageDir := t.TempDir()
+	require.NoError(t, createProfile(Options{PackagePath: elasticPackageDir, Name: "old"}))
+
+	p, err := loadProfile(elasticPackageDir, "old")
+	require.NoError(t, err)
+
+	// Simulate profile created by an older version: kibana.config.yml had different defaults
+	// and was customized by the user, package registry config wasn't changed, config.yml didn't exist.
+	kibanaPath := p.FetchPath(KibanaConfigFile)
+	oldKibanaDefault := "server.name: kibana\n" + kibanaConfigYml
+	require.NoError(t, writeFile(p.defaultFilePath(kibanaPath), oldKibanaDefault))
+	require.NoError(t, writeFile(kibanaPath, "server.name: kibana\n"+kibanaConfigYml+"logging.verbose: true\n"))
+
+	registryPath := p.FetchPath(PackageRegistryConfigFile)
+	require.NoError(t, writeFile(p.defaultFilePath(registryPath), "old: default\n"))
+	require.NoError(t, writeFile(registryPath, "old: default\n"))
+
+	snapshotPath := p.FetchPath(SnapshotFile)
+	require.NoError(t, writeFile(p.defaultFilePath(snapshotPath), "version: '2.3'\n"))
+	require.NoError(t, writeFile(snapshotPath, "version: '2.4'\n"))
+
+	require.NoError(t, os.Remove(p.FetchPath(ProfileConfigFile)))
+
+	checks, err := checkProfile(elasticPackageDir, "old")
+	require.NoError(t, err)
+	statuses := map[string]FileStatus{}
+	for _, c := range checks {
+		statuses[c.File] = c.Status
+	}
+	require.Equal(t, map[string]FileStatus{
+		string(KibanaConfigFile):              FileMergeable,
+		string(PackageRegistryConfigFile):     FileOutdated,
+		string(PackageRegistryDockerfileFile): FileUpToDate,
+		string(SnapshotFile):                  FileConflict,
+		string(ProfileConfigFile):             FileMissing,
+	}, statuses)
+
+	_, err = upgradeProfile(elasticPackageDir, "old", false)
+	require.NoError(t, err)
+
+	kibanaConfig, err := ioutil.ReadFile(kibanaPath)
+	require.NoError(t, err)
+	require.Equal(t, kibanaConfigYml+"logging.verbose: true\n", string(kibanaConfig))
+
+	checks, err = checkProfile(elasticPackageDir, "old")
+	require.NoError(t, err)
+	for _, c := range checks {
+		switch c.File {
+		case string(KibanaConfigFile):
+			require.Equal(t, FileCustomized, c.Status)
+		case string(SnapshotFile):
+			require.Equal(t, FileConflict, c.Status)
+		default:
+			require.Equalf(t, FileUpToDate, c.Status, "unexpected status of %s", c.File)
+		}
+	}
+}
+
+func TestUpgradeProfile_OverwriteModified(t *testing.T) {
+	elasticPackageDir := t.TempDir()
+	require.NoError(t, createProfile(Options{PackagePath: elasticPackageDir, Name: "legacy"}))
+
+	p, err := loadProfile(elasticPackageDir, "legacy")
+	require.NoError(t, err)
+
+	// Simulate profile created by a version, which didn't store defaults.
+	require.NoError(t, os.RemoveAll(filepath.Join(p.ProfilePath, profileDefaultsPath)))
+	kibanaPath := p.FetchPath(KibanaConfigFile)
+	require.NoError(t, writeFile(kibanaPath, "server.name: kibana\n"+kibanaConfigYml))
+
+	require.Equal(t, FileModified, profileFileStatus(t, elasticPackageDir, "legacy", KibanaConfigFile))
+
+	_, err = upgradeProfile(elasticPackageDir, "legacy", false)
+	require.NoError(t, err)
+	require.Equal(t, FileModified, profileFileStatus(t, elasticPackageDir, "legacy", KibanaConfigFile))
+
+	_, err = upgradeProfile(elasticPackageDir, "legacy", true)
+	require.NoError(t, err)
+	require.Equal(t, FileUpToDate, profileFileStatus(t, elasticPackageDir, "legacy", KibanaConfigFile))
+	require.FileExists(t, p.defaultFilePath(kibanaPath))
+}
+
+func TestCreateProfileFrom_KeepsDefaults(t *testing.T) {
+	elasticPackageDir := t.TempDir()
+	require.NoError(t, createProfile(Options{PackagePath: elasticPackageDir, Name: "old"}))
+
+	p, err := loadProfile(elasticPackageDir, "old")
+	require.NoError(t, err)
+
+	// Package registry config is outdated, Kibana config has unknown default.
+	registryPath := p.FetchPath(PackageRegistryConfigFile)
+	require.NoError(t, writeFile(p.defaultFilePath(registryPath), "old: default\n"))
+	require.NoError(t, writeFile(registryPath, "old: default\n"))
+
+	kibanaPath := p.FetchPath(KibanaConfigFile)
+	require.NoError(t, os.Remove(p.defaultFilePath(kibanaPath)))
+	require.NoError(t, writeFile(kibanaPath, "server.name: kibana\n"))
+
+	require.NoError(t, createProfileFrom(Options{PackagePath: elasticPackageDir, Name: "copy", FromProfile: "old"}))
+
+	require.Equal(t, FileOutdated, profileFileStatus(t, elasticPackageDir, "copy", PackageRegistryConfigFile))
+	require.Equal(t, FileModified, profileFileStatus(t, elasticPackageDir, "copy", KibanaConfigFile))
+	require.Equal(t, FileUpToDate, profileFileStatus(t, elasticPackageDir, "copy", SnapshotFile))
+}
+
+func profileFileStatus(t *testing.T, elasticPackageDir, profileName string, file configFile) FileStatus {
+	checks, err := checkProfile(elasticPackageDir, profileName)
+	require.NoError(t, err)
+	for _, c := range checks {
+		if c.File == string(file) {
+			return c.Status
+		}
+	}
+	require.Failf(t, "file not checked", "file: %s", file)
+	return ""
+}
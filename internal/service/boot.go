@@ -24,6 +24,7 @@ type Options struct {
 	ServiceName        string
 	PackageRootPath    string
 	DataStreamRootPath string
+	DeployerName       string
 }
 
 // BootUp function boots up the service stack.
@@ -32,6 +33,7 @@ func BootUp(options Options) error {
 	serviceDeployer, err := servicedeployer.Factory(servicedeployer.FactoryOptions{
 		PackageRootPath:    options.DataStreamRootPath,
 		DataStreamRootPath: options.DataStreamRootPath,
+		DeployerName:       options.DeployerName,
 	})
 	if err != nil {
 		return errors.Wrap(err, "can't create the service deployer instance")
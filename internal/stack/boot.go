@@ -12,14 +12,24 @@ import (
 	"github.com/elastic/elastic-package/internal/builder"
 	"github.com/elastic/elastic-package/internal/configuration/locations"
 	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/profile"
 )
 
 // DockerComposeProjectName is the name of the Docker Compose project used to boot up
 // Elastic Stack containers.
 const DockerComposeProjectName = "elastic-package-stack"
 
-// BootUp function boots up the Elastic stack.
+// BootUp function boots up the Elastic stack. For remote profiles, only the Elastic Agent enrolled
+// into the remote Fleet is started.
 func BootUp(options Options) error {
+	config, err := options.Profile.Config()
+	if err != nil {
+		return errors.Wrap(err, "can't read profile configuration")
+	}
+	if config.IsRemote() {
+		return bootUpRemote(options, config.Remote)
+	}
+
 	buildPackagesPath, found, err := builder.FindBuildPackagesDirectory()
 	if err != nil {
 		return errors.Wrap(err, "finding build packages directory failed")
@@ -70,3 +80,23 @@ func TearDown(options Options) error {
 	}
 	return nil
 }
+
+func bootUpRemote(options Options, remote profile.RemoteConfig) error {
+	if remote.FleetServerURL == "" {
+		return errors.New("URL of the Fleet Server is required to enroll the Elastic Agent (remote.fleet_server_url)")
+	}
+
+	fmt.Println("Remote Elastic stack is used, only the Elastic Agent will be started locally.")
+	token, err := fleetEnrollmentToken(remoteEnvironment(remote))
+	if err != nil {
+		return errors.Wrap(err, "can't read enrollment token of the remote Fleet")
+	}
+
+	// The remote project consists only of the Elastic Agent.
+	options.Services = nil
+	err = dockerComposeUp(options, fleetEnrollmentTokenEnv+"="+token)
+	if err != nil {
+		return errors.Wrap(err, "running docker-compose failed")
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package stack
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -15,14 +16,14 @@ import (
 )
 
 func dockerComposeBuild(options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName, options.Profile.FetchPath(profile.SnapshotFile))
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
-		return errors.Wrap(err, "could not create docker compose project")
+		return err
 	}
 
 	opts := compose.CommandOptions{
 		Env:      options.Profile.ComposeEnvVars(),
-		Services: withIsReadyServices(withDependentServices(options.Services)),
+		Services: withIsReadyServices(withDependentServices(options.Services, options.Profile)),
 	}
 
 	if err := c.Build(opts); err != nil {
@@ -32,9 +33,9 @@ func dockerComposeBuild(options Options) error {
 }
 
 func dockerComposePull(options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName, options.Profile.FetchPath(profile.SnapshotFile))
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
-		return errors.Wrap(err, "could not create docker compose project")
+		return err
 	}
 
 	appConfig, err := install.Configuration()
@@ -44,7 +45,7 @@ func dockerComposePull(options Options) error {
 
 	opts := compose.CommandOptions{
 		Env:      append(appConfig.StackImageRefs(options.StackVersion).AsEnv(), options.Profile.ComposeEnvVars()...),
-		Services: withIsReadyServices(withDependentServices(options.Services)),
+		Services: withIsReadyServices(withDependentServices(options.Services, options.Profile)),
 	}
 
 	if err := c.Pull(opts); err != nil {
@@ -53,10 +54,11 @@ func dockerComposePull(options Options) error {
 	return nil
 }
 
-func dockerComposeUp(options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName, options.Profile.FetchPath(profile.SnapshotFile))
+// dockerComposeUp starts services of the stack. Additional environment variables are passed to Docker Compose.
+func dockerComposeUp(options Options, env ...string) error {
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
-		return errors.Wrap(err, "could not create docker compose project")
+		return err
 	}
 
 	var args []string
@@ -69,10 +71,11 @@ func dockerComposeUp(options Options) error {
 		return errors.Wrap(err, "can't read application configuration")
 	}
 
+	env = append(env, options.Profile.ComposeEnvVars()...)
 	opts := compose.CommandOptions{
-		Env:       append(appConfig.StackImageRefs(options.StackVersion).AsEnv(), options.Profile.ComposeEnvVars()...),
+		Env:       append(appConfig.StackImageRefs(options.StackVersion).AsEnv(), env...),
 		ExtraArgs: args,
-		Services:  withIsReadyServices(withDependentServices(options.Services)),
+		Services:  withIsReadyServices(withDependentServices(options.Services, options.Profile)),
 	}
 
 	if err := c.Up(opts); err != nil {
@@ -82,9 +85,9 @@ func dockerComposeUp(options Options) error {
 }
 
 func dockerComposeDown(options Options) error {
-	c, err := compose.NewProject(DockerComposeProjectName, options.Profile.FetchPath(profile.SnapshotFile))
+	c, err := newComposeProject(options.Profile)
 	if err != nil {
-		return errors.Wrap(err, "could not create docker compose project")
+		return err
 	}
 
 	if err := c.Down(compose.CommandOptions{}); err != nil {
@@ -93,10 +96,10 @@ func dockerComposeDown(options Options) error {
 	return nil
 }
 
-func dockerComposeLogs(serviceName string, snapshotFile string) ([]byte, error) {
-	c, err := compose.NewProject(DockerComposeProjectName, snapshotFile)
+func dockerComposeLogs(serviceName string, elasticStackProfile *profile.Profile) ([]byte, error) {
+	c, err := newComposeProject(elasticStackProfile)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not create docker compose project")
+		return nil, err
 	}
 
 	opts := compose.CommandOptions{
@@ -110,13 +113,27 @@ func dockerComposeLogs(serviceName string, snapshotFile string) ([]byte, error)
 	return out, nil
 }
 
-func withDependentServices(services []string) []string {
+func withDependentServices(services []string, elasticStackProfile *profile.Profile) []string {
 	for _, aService := range services {
-		if aService == "elastic-agent" {
+		if aService == "elastic-agent" || strings.HasPrefix(aService, "elastic-agent-") {
 			return []string{} // elastic-agent service requires to load all other services
 		}
 	}
-	return services
+
+	config, err := elasticStackProfile.Config()
+	if err != nil {
+		return services // invalid configuration is reported while rendering Docker Compose files
+	}
+
+	var allServices []string
+	for _, aService := range services {
+		allServices = append(allServices, aService)
+		if aService == "elasticsearch" {
+			// additional Elasticsearch nodes form a single cluster
+			allServices = append(allServices, elasticsearchNodes(config.Stack)[1:]...)
+		}
+	}
+	return allServices
 }
 
 func withIsReadyServices(services []string) []string {
@@ -0,0 +1,414 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/compose"
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+const (
+	// composeOverrideFile is the Docker Compose file rendered from the profile configuration,
+	// which extends the snapshot file.
+	composeOverrideFile = "snapshot.override.yml"
+
+	// kibanaGeneratedConfigFile is the Kibana configuration file with additional settings
+	// defined in the profile configuration.
+	kibanaGeneratedConfigFile = "kibana.generated.yml"
+
+	elasticsearchCertsService = "elasticsearch-certs"
+	elasticsearchCertsVolume  = "elasticsearch_certs"
+	elasticsearchCertsDir     = "/usr/share/elasticsearch/config/certs"
+)
+
+type composeFile struct {
+	Version  string                            `yaml:"version"`
+	Services map[string]map[string]interface{} `yaml:"services"`
+	Volumes  map[string]interface{}            `yaml:"volumes,omitempty"`
+}
+
+// newComposeProject function creates the Docker Compose project of the Elastic stack. The project consists of
+// the snapshot file and the override file rendered from the profile configuration.
+func newComposeProject(elasticStackProfile *profile.Profile) (*compose.Project, error) {
+	paths, err := composeFiles(elasticStackProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't prepare Docker Compose files")
+	}
+
+	p, err := compose.NewProject(DockerComposeProjectName, paths...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create docker compose project")
+	}
+	return p, nil
+}
+
+func composeFiles(elasticStackProfile *profile.Profile) ([]string, error) {
+	config, err := elasticStackProfile.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read profile configuration")
+	}
+
+	if config.IsRemote() {
+		return remoteComposeFiles(elasticStackProfile, config.Remote)
+	}
+
+	if config.Stack.TLS.Enabled {
+		err = elasticStackProfile.EnsureCertificates()
+		if err != nil {
+			return nil, errors.Wrap(err, "can't create TLS certificates")
+		}
+	}
+
+	kibanaConfig, err := ioutil.ReadFile(elasticStackProfile.FetchPath(profile.KibanaConfigFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading Kibana config file")
+	}
+
+	override, kibanaGenerated, err := renderComposeOverride(config.Stack, kibanaConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't render Docker Compose override file")
+	}
+
+	if kibanaGenerated != nil {
+		err = writeGeneratedFile(filepath.Join(elasticStackProfile.ProfileStackPath, kibanaGeneratedConfigFile), kibanaGenerated)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	overridePath := filepath.Join(elasticStackProfile.ProfileStackPath, composeOverrideFile)
+	err = writeGeneratedFile(overridePath, override)
+	if err != nil {
+		return nil, err
+	}
+	return []string{elasticStackProfile.FetchPath(profile.SnapshotFile), overridePath}, nil
+}
+
+// generatedFilesMutex serializes writes of generated files, as Docker Compose projects may be created concurrently.
+var generatedFilesMutex sync.Mutex
+
+// writeGeneratedFile writes the file, unless it already has the same content. This way files aren't modified
+// while they may be read by running Docker Compose commands.
+func writeGeneratedFile(path string, body []byte) error {
+	generatedFilesMutex.Lock()
+	defer generatedFilesMutex.Unlock()
+
+	current, err := ioutil.ReadFile(path)
+	if err == nil && bytes.Equal(current, body) {
+		return nil
+	}
+
+	err = ioutil.WriteFile(path, body, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "writing file failed (path: %s)", path)
+	}
+	return nil
+}
+
+// renderComposeOverride renders the Docker Compose override file from the stack configuration. If additional Kibana
+// settings are defined or TLS is enabled, it renders also the Kibana configuration file replacing the one from the profile.
+func renderComposeOverride(config profile.StackConfig, kibanaConfig []byte) ([]byte, []byte, error) {
+	f := composeFile{
+		Version:  "2.3",
+		Services: map[string]map[string]interface{}{},
+	}
+
+	elasticsearchService := map[string]interface{}{
+		"environment": []string{javaOptsEnv(config.Elasticsearch.HeapSize)},
+	}
+	f.Services["elasticsearch"] = elasticsearchService
+
+	nodes := elasticsearchNodes(config)
+	if len(nodes) > 1 {
+		f.Services[elasticsearchCertsService] = elasticsearchCertsServiceDefinition()
+		f.Volumes = map[string]interface{}{elasticsearchCertsVolume: map[string]interface{}{}}
+
+		elasticsearchService["environment"] = append(multiNodeEnv("elasticsearch", nodes), javaOptsEnv(config.Elasticsearch.HeapSize))
+		elasticsearchService["depends_on"] = certsDependency()
+		elasticsearchService["volumes"] = []string{elasticsearchCertsVolume + ":" + elasticsearchCertsDir}
+
+		for _, node := range nodes[1:] {
+			f.Services[node] = additionalElasticsearchNode(node, nodes, config.Elasticsearch.HeapSize)
+		}
+	}
+
+	for _, node := range nodes {
+		service := f.Services[node]
+		env := service["environment"].([]string)
+		service["environment"] = append(env, settingsEnv(config.Elasticsearch.Settings)...)
+	}
+
+	agents := additionalElasticAgents(config)
+	for _, agent := range agents {
+		f.Services[agent] = map[string]interface{}{
+			"extends": map[string]interface{}{
+				"file":    string(profile.SnapshotFile),
+				"service": "elastic-agent",
+			},
+			"hostname": "docker-fleet-agent-" + strings.TrimPrefix(agent, "elastic-agent-"),
+			"depends_on": map[string]interface{}{
+				"fleet-server": map[string]interface{}{"condition": "service_healthy"},
+			},
+		}
+	}
+
+	kibanaSettings := map[string]interface{}{}
+	if config.TLS.Enabled {
+		kibanaSettings = applyTLS(&f, nodes, agents)
+	}
+	for key, value := range config.Kibana.Settings {
+		kibanaSettings[key] = value
+	}
+
+	var kibanaGenerated []byte
+	if len(kibanaSettings) > 0 {
+		var err error
+		kibanaGenerated, err = mergeKibanaSettings(kibanaConfig, kibanaSettings)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "can't merge Kibana settings")
+		}
+
+		kibanaService, found := f.Services["kibana"]
+		if !found {
+			kibanaService = map[string]interface{}{}
+			f.Services["kibana"] = kibanaService
+		}
+		kibanaService["volumes"] = appendVolume(kibanaService["volumes"], "./"+kibanaGeneratedConfigFile+":/usr/share/kibana/config/kibana.yml")
+	}
+
+	for name, definition := range config.Services {
+		if _, found := f.Services[name]; found || isStackService(name) {
+			return nil, nil, fmt.Errorf("side-car service %q conflicts with a stack service", name)
+		}
+		f.Services[name] = definition
+	}
+
+	// Every additional service gets the auxiliary service used to wait until it's ready.
+	for _, name := range additionalServices(config) {
+		condition := "service_started"
+		if _, found := f.Services[name]["healthcheck"]; found || !isSidecarService(config, name) {
+			condition = "service_healthy"
+		}
+		f.Services[name+isReadyServiceSuffix] = map[string]interface{}{
+			"image": "tianon/true",
+			"depends_on": map[string]interface{}{
+				name: map[string]interface{}{"condition": condition},
+			},
+		}
+	}
+
+	override, err := yaml.Marshal(&f)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshalling Docker Compose file failed")
+	}
+	return override, kibanaGenerated, nil
+}
+
+// additionalServices function returns names of services added to the stack by the profile configuration.
+func additionalServices(config profile.StackConfig) []string {
+	services := elasticsearchNodes(config)[1:]
+	services = append(services, additionalElasticAgents(config)...)
+	services = append(services, sidecarServices(config)...)
+	return services
+}
+
+func elasticsearchNodes(config profile.StackConfig) []string {
+	nodes := []string{"elasticsearch"}
+	for i := 2; i <= config.Elasticsearch.Nodes; i++ {
+		nodes = append(nodes, fmt.Sprintf("elasticsearch-%d", i))
+	}
+	return nodes
+}
+
+func additionalElasticAgents(config profile.StackConfig) []string {
+	var agents []string
+	for i := 2; i <= config.ElasticAgent.Replicas; i++ {
+		agents = append(agents, fmt.Sprintf("elastic-agent-%d", i))
+	}
+	return agents
+}
+
+func sidecarServices(config profile.StackConfig) []string {
+	var services []string
+	for name := range config.Services {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services
+}
+
+func isStackService(name string) bool {
+	for _, service := range observedServices {
+		if service == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isSidecarService(config profile.StackConfig, name string) bool {
+	_, found := config.Services[name]
+	return found
+}
+
+// SidecarServices function returns names of side-car services defined in the profile configuration.
+func SidecarServices(elasticStackProfile *profile.Profile) ([]string, error) {
+	config, err := elasticStackProfile.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read profile configuration")
+	}
+	return sidecarServices(config.Stack), nil
+}
+
+// ElasticAgentServices function returns names of all Elastic Agent services started by the profile,
+// including additional replicas.
+func ElasticAgentServices(elasticStackProfile *profile.Profile) ([]string, error) {
+	config, err := elasticStackProfile.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read profile configuration")
+	}
+	services := []string{"elastic-agent"}
+	if config.IsRemote() {
+		return services, nil
+	}
+	return append(services, additionalElasticAgents(config.Stack)...), nil
+}
+
+func javaOptsEnv(heapSize string) string {
+	return fmt.Sprintf("ES_JAVA_OPTS=-Xms%s -Xmx%s", heapSize, heapSize)
+}
+
+func settingsEnv(settings map[string]interface{}) []string {
+	var env []string
+	for key, value := range settings {
+		env = append(env, fmt.Sprintf("%s=%s", key, settingValue(value)))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// settingValue formats the value of the Elasticsearch setting, passed as environment variable.
+func settingValue(value interface{}) string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	var values []string
+	for _, v := range list {
+		values = append(values, fmt.Sprint(v))
+	}
+	return strings.Join(values, ",")
+}
+
+// multiNodeEnv returns settings of the Elasticsearch node required to form a cluster. Nodes of the cluster
+// communicate using TLS, as it's required by Elasticsearch running in production mode with security enabled.
+func multiNodeEnv(node string, nodes []string) []string {
+	return []string{
+		"node.name=" + node,
+		"transport.host=0.0.0.0",
+		"discovery.seed_hosts=" + strings.Join(nodes, ","),
+		"cluster.initial_master_nodes=" + strings.Join(nodes, ","),
+		"xpack.security.transport.ssl.enabled=true",
+		"xpack.security.transport.ssl.verification_mode=certificate",
+		"xpack.security.transport.ssl.key=certs/transport/transport.key",
+		"xpack.security.transport.ssl.certificate=certs/transport/transport.crt",
+		"xpack.security.transport.ssl.certificate_authorities=certs/ca/ca.crt",
+	}
+}
+
+// additionalElasticsearchNode returns the definition of the additional Elasticsearch node. Settings of the node
+// must be kept in sync with the "elasticsearch" service of the snapshot file.
+func additionalElasticsearchNode(node string, nodes []string, heapSize string) map[string]interface{} {
+	env := append([]string{
+		javaOptsEnv(heapSize),
+		"network.host=",
+		"http.host=0.0.0.0",
+		"indices.id_field_data.enabled=true",
+		"xpack.license.self_generated.type=trial",
+		"xpack.security.enabled=true",
+		"xpack.security.authc.api_key.enabled=true",
+		"ELASTIC_PASSWORD=changeme",
+		"script.context.template.max_compilations_rate=unlimited",
+		"script.context.ingest.cache_max_size=2000",
+		"script.context.processor_conditional.cache_max_size=2000",
+		"script.context.template.cache_max_size=2000",
+		"ingest.geoip.downloader.enabled=false",
+	}, multiNodeEnv(node, nodes)...)
+
+	return map[string]interface{}{
+		"image": "${ELASTICSEARCH_IMAGE_REF}",
+		"healthcheck": map[string]interface{}{
+			"test":     []string{"CMD", "curl", "-f", "-u", "elastic:changeme", "http://127.0.0.1:9200/"},
+			"retries":  300,
+			"interval": "1s",
+		},
+		"environment": env,
+		"depends_on":  certsDependency(),
+		"volumes":     []string{elasticsearchCertsVolume + ":" + elasticsearchCertsDir},
+	}
+}
+
+// elasticsearchCertsServiceDefinition returns the definition of the service generating the certificate authority
+// and the certificate used by Elasticsearch nodes to secure the transport layer. The service is healthy once
+// certificates are ready.
+func elasticsearchCertsServiceDefinition() map[string]interface{} {
+	script := `cd ` + elasticsearchCertsDir + ` && ` +
+		`if [ ! -f ca/ca.crt ]; then ` +
+		`/usr/share/elasticsearch/bin/elasticsearch-certutil ca --silent --pem --out ca.zip && unzip -o ca.zip; fi && ` +
+		`if [ ! -f transport/transport.crt ]; then ` +
+		`/usr/share/elasticsearch/bin/elasticsearch-certutil cert --silent --pem --name transport ` +
+		`--ca-cert ca/ca.crt --ca-key ca/ca.key --out transport.zip && unzip -o transport.zip; fi && ` +
+		`chown -R 1000:0 . && touch ready && tail -f /dev/null`
+
+	return map[string]interface{}{
+		"image":   "${ELASTICSEARCH_IMAGE_REF}",
+		"user":    "0",
+		"command": []string{"bash", "-c", script},
+		"healthcheck": map[string]interface{}{
+			"test":     []string{"CMD", "test", "-f", elasticsearchCertsDir + "/ready"},
+			"retries":  300,
+			"interval": "1s",
+		},
+		"volumes": []string{elasticsearchCertsVolume + ":" + elasticsearchCertsDir},
+	}
+}
+
+func certsDependency() map[string]interface{} {
+	return map[string]interface{}{
+		elasticsearchCertsService: map[string]interface{}{"condition": "service_healthy"},
+	}
+}
+
+// mergeKibanaSettings returns the Kibana configuration with additional settings. Settings already defined
+// in the configuration are overridden.
+func mergeKibanaSettings(kibanaConfig []byte, settings map[string]interface{}) ([]byte, error) {
+	merged := map[string]interface{}{}
+	err := yaml.Unmarshal(kibanaConfig, &merged)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling Kibana configuration failed")
+	}
+
+	for key, value := range settings {
+		merged[key] = value
+	}
+
+	body, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling Kibana configuration failed")
+	}
+	return body, nil
+}
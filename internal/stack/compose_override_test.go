@@ -0,0 +1,156 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+const testKibanaConfig = `
+server.name: kibana
+elasticsearch.username: elastic
+`
+
+func defaultStackConfig() profile.StackConfig {
+	return profile.StackConfig{
+		Elasticsearch: profile.ElasticsearchConfig{
+			HeapSize: profile.DefaultElasticsearchHeapSize,
+			Nodes:    profile.DefaultElasticsearchNodes,
+		},
+		ElasticAgent: profile.ElasticAgentConfig{
+			Replicas: profile.DefaultElasticAgentReplicas,
+		},
+	}
+}
+
+func renderTestComposeOverride(t *testing.T, config profile.StackConfig) (composeFile, []byte) {
+	override, kibanaGenerated, err := renderComposeOverride(config, []byte(testKibanaConfig))
+	require.NoError(t, err)
+
+	var f composeFile
+	require.NoError(t, yaml.Unmarshal(override, &f))
+	return f, kibanaGenerated
+}
+
+func TestRenderComposeOverride_Defaults(t *testing.T) {
+	f, kibanaGenerated := renderTestComposeOverride(t, defaultStackConfig())
+
+	require.Nil(t, kibanaGenerated)
+	require.Len(t, f.Services, 1)
+	require.Equal(t, []interface{}{"ES_JAVA_OPTS=-Xms1g -Xmx1g"}, f.Services["elasticsearch"]["environment"])
+}
+
+func TestRenderComposeOverride_MultiNode(t *testing.T) {
+	config := defaultStackConfig()
+	config.Elasticsearch.Nodes = 3
+	config.Elasticsearch.HeapSize = "2g"
+	config.Elasticsearch.Settings = map[string]interface{}{
+		"indices.query.bool.max_clause_count":             4096,
+		"cluster.routing.allocation.awareness.attributes": []interface{}{"rack", "zone"},
+	}
+
+	f, _ := renderTestComposeOverride(t, config)
+
+	for _, service := range []string{"elasticsearch", "elasticsearch-2", "elasticsearch-3", elasticsearchCertsService,
+		"elasticsearch-2_is_ready", "elasticsearch-3_is_ready"} {
+		require.Contains(t, f.Services, service)
+	}
+	require.Contains(t, f.Volumes, elasticsearchCertsVolume)
+
+	for _, node := range []string{"elasticsearch", "elasticsearch-3"} {
+		env := f.Services[node]["environment"]
+		require.Contains(t, env, "ES_JAVA_OPTS=-Xms2g -Xmx2g")
+		require.Contains(t, env, "discovery.seed_hosts=elasticsearch,elasticsearch-2,elasticsearch-3")
+		require.Contains(t, env, "indices.query.bool.max_clause_count=4096")
+		require.Contains(t, env, "cluster.routing.allocation.awareness.attributes=rack,zone")
+	}
+	require.Contains(t, f.Services["elasticsearch-2"]["environment"], "node.name=elasticsearch-2")
+}
+
+func TestRenderComposeOverride_AgentsAndSidecars(t *testing.T) {
+	config := defaultStackConfig()
+	config.ElasticAgent.Replicas = 2
+	config.Services = map[string]map[string]interface{}{
+		"logstash": {"image": "docker.elastic.co/logstash/logstash:7.15.0"},
+	}
+
+	f, _ := renderTestComposeOverride(t, config)
+
+	require.Equal(t, "docker-fleet-agent-2", f.Services["elastic-agent-2"]["hostname"])
+	require.Equal(t, "docker.elastic.co/logstash/logstash:7.15.0", f.Services["logstash"]["image"])
+	require.Equal(t, map[string]interface{}{"logstash": map[string]interface{}{"condition": "service_started"}},
+		f.Services["logstash_is_ready"]["depends_on"])
+	require.Equal(t, map[string]interface{}{"elastic-agent-2": map[string]interface{}{"condition": "service_healthy"}},
+		f.Services["elastic-agent-2_is_ready"]["depends_on"])
+}
+
+func TestAdditionalServices(t *testing.T) {
+	config := defaultStackConfig()
+	config.Elasticsearch.Nodes = 2
+	config.ElasticAgent.Replicas = 3
+	config.Services = map[string]map[string]interface{}{
+		"logstash": {"image": "docker.elastic.co/logstash/logstash:7.15.0"},
+	}
+
+	require.Equal(t, []string{"elasticsearch-2", "elastic-agent-2", "elastic-agent-3", "logstash"}, additionalServices(config))
+}
+
+func TestRenderComposeOverride_ConflictingSidecar(t *testing.T) {
+	config := defaultStackConfig()
+	config.Services = map[string]map[string]interface{}{
+		"kibana": {"image": "kibana"},
+	}
+
+	_, _, err := renderComposeOverride(config, []byte(testKibanaConfig))
+	require.Error(t, err)
+}
+
+func TestRenderComposeOverride_KibanaSettings(t *testing.T) {
+	config := defaultStackConfig()
+	config.Kibana.Settings = map[string]interface{}{
+		"server.name":     "custom",
+		"logging.verbose": true,
+	}
+
+	f, kibanaGenerated := renderTestComposeOverride(t, config)
+	require.Contains(t, f.Services, "kibana")
+
+	var kibanaConfig map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(kibanaGenerated, &kibanaConfig))
+	require.Equal(t, map[string]interface{}{
+		"server.name":            "custom",
+		"elasticsearch.username": "elastic",
+		"logging.verbose":        true,
+	}, kibanaConfig)
+}
+
+func TestRenderComposeOverride_TLS(t *testing.T) {
+	config := defaultStackConfig()
+	config.TLS.Enabled = true
+	config.ElasticAgent.Replicas = 2
+	config.Kibana.Settings = map[string]interface{}{
+		"server.name": "custom",
+	}
+
+	f, kibanaGenerated := renderTestComposeOverride(t, config)
+
+	require.Contains(t, f.Services["elasticsearch"]["environment"], "xpack.security.http.ssl.enabled=true")
+	require.Contains(t, f.Services["fleet-server"]["environment"], "KIBANA_FLEET_HOST=https://kibana:5601")
+	for _, agent := range []string{"elastic-agent", "elastic-agent-2"} {
+		require.Contains(t, f.Services[agent]["environment"], "FLEET_URL=https://fleet-server:8220")
+	}
+	require.Contains(t, f.Services["kibana"]["volumes"], "./"+kibanaGeneratedConfigFile+":/usr/share/kibana/config/kibana.yml")
+
+	var kibanaConfig map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(kibanaGenerated, &kibanaConfig))
+	require.Equal(t, "custom", kibanaConfig["server.name"])
+	require.Equal(t, true, kibanaConfig["server.ssl.enabled"])
+	require.Equal(t, []interface{}{"https://elasticsearch:9200"}, kibanaConfig["elasticsearch.hosts"])
+}
@@ -0,0 +1,145 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+const (
+	// remoteComposeFile is the Docker Compose file rendered for remote profiles. It defines only the Elastic Agent
+	// enrolled into the remote Fleet.
+	remoteComposeFile = "remote.yml"
+
+	// fleetEnrollmentTokenEnv passes the enrollment token to Docker Compose, so it isn't stored in the profile.
+	fleetEnrollmentTokenEnv = "FLEET_ENROLLMENT_TOKEN"
+
+	remoteAgentCAPath = "/etc/elastic-package/remote-ca.pem"
+)
+
+func remoteComposeFiles(elasticStackProfile *profile.Profile, remote profile.RemoteConfig) ([]string, error) {
+	body, err := renderRemoteCompose(remote)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't render Docker Compose file")
+	}
+
+	path := filepath.Join(elasticStackProfile.ProfileStackPath, remoteComposeFile)
+	err = writeGeneratedFile(path, body)
+	if err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// renderRemoteCompose renders the Docker Compose file with the Elastic Agent enrolled into the remote Fleet.
+// The agent uses the same hostname and network as the agent of the local stack, so system tests work in the same way.
+func renderRemoteCompose(remote profile.RemoteConfig) ([]byte, error) {
+	env := []string{
+		"FLEET_ENROLL=1",
+		"FLEET_URL=" + remote.FleetServerURL,
+		fleetEnrollmentTokenEnv + "=${" + fleetEnrollmentTokenEnv + ":-}",
+		"STATE_PATH=/usr/share/elastic-agent",
+	}
+	volumes := []interface{}{
+		map[string]interface{}{
+			"type":   "bind",
+			"source": "../../../tmp/service_logs/",
+			"target": "/tmp/service_logs/",
+		},
+	}
+	if remote.CACert != "" {
+		env = append(env, "FLEET_CA="+remoteAgentCAPath)
+		volumes = append(volumes, remote.CACert+":"+remoteAgentCAPath+":ro")
+	}
+
+	f := composeFile{
+		Version: "2.3",
+		Services: map[string]map[string]interface{}{
+			"elastic-agent": {
+				"image": "${ELASTIC_AGENT_IMAGE_REF}",
+				"healthcheck": map[string]interface{}{
+					"test":     "elastic-agent status",
+					"retries":  90,
+					"interval": "1s",
+				},
+				"hostname":    "docker-fleet-agent",
+				"environment": env,
+				"volumes":     volumes,
+			},
+			"elastic-agent" + isReadyServiceSuffix: {
+				"image": "tianon/true",
+				"depends_on": map[string]interface{}{
+					"elastic-agent": map[string]interface{}{"condition": "service_healthy"},
+				},
+			},
+		},
+	}
+
+	body, err := yaml.Marshal(&f)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling Docker Compose file failed")
+	}
+	return body, nil
+}
+
+// fleetEnrollmentToken function returns the enrollment token of the default agent policy of the remote Fleet.
+func fleetEnrollmentToken(env map[string]string) (string, error) {
+	body, err := kibanaGet(env, "/api/fleet/agent_policies?perPage=1000")
+	if err != nil {
+		return "", errors.Wrap(err, "can't list agent policies")
+	}
+
+	var policies struct {
+		Items []struct {
+			ID        string `json:"id"`
+			IsDefault bool   `json:"is_default"`
+		} `json:"items"`
+	}
+	err = json.Unmarshal(body, &policies)
+	if err != nil {
+		return "", errors.Wrap(err, "can't unmarshal agent policies")
+	}
+
+	var policyID string
+	for _, p := range policies.Items {
+		if p.IsDefault {
+			policyID = p.ID
+			break
+		}
+	}
+	if policyID == "" {
+		return "", errors.New("default agent policy not found")
+	}
+
+	body, err = kibanaGet(env, "/api/fleet/enrollment-api-keys?perPage=1000")
+	if err != nil {
+		return "", errors.Wrap(err, "can't list enrollment API keys")
+	}
+
+	var keys struct {
+		List []struct {
+			APIKey   string `json:"api_key"`
+			PolicyID string `json:"policy_id"`
+			Active   bool   `json:"active"`
+		} `json:"list"`
+	}
+	err = json.Unmarshal(body, &keys)
+	if err != nil {
+		return "", errors.Wrap(err, "can't unmarshal enrollment API keys")
+	}
+
+	for _, k := range keys.List {
+		if k.PolicyID == policyID && k.Active {
+			return k.APIKey, nil
+		}
+	}
+	return "", errors.Errorf("active enrollment API key not found (policy ID: %s)", policyID)
+}
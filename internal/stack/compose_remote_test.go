@@ -0,0 +1,66 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+func TestRenderRemoteCompose(t *testing.T) {
+	body, err := renderRemoteCompose(profile.RemoteConfig{
+		FleetServerURL: "https://fleet.example.com:443",
+		CACert:         "/tmp/ca.pem",
+	})
+	require.NoError(t, err)
+
+	var f composeFile
+	require.NoError(t, yaml.Unmarshal(body, &f))
+	require.Len(t, f.Services, 2)
+
+	agent := f.Services["elastic-agent"]
+	require.Equal(t, "docker-fleet-agent", agent["hostname"])
+	require.Contains(t, agent["environment"], "FLEET_URL=https://fleet.example.com:443")
+	require.Contains(t, agent["environment"], "FLEET_CA="+remoteAgentCAPath)
+	require.Contains(t, agent["volumes"], "/tmp/ca.pem:"+remoteAgentCAPath+":ro")
+}
+
+func TestFleetEnrollmentToken(t *testing.T) {
+	kibana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "ApiKey Zm9vOmJhcg==", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/api/fleet/agent_policies":
+			w.Write([]byte(`{"items": [{"id": "other"}, {"id": "default-policy", "is_default": true}]}`))
+		case "/api/fleet/enrollment-api-keys":
+			w.Write([]byte(`{"list": [
+				{"api_key": "other-key", "policy_id": "other", "active": true},
+				{"api_key": "inactive-key", "policy_id": "default-policy", "active": false},
+				{"api_key": "default-key", "policy_id": "default-policy", "active": true}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer kibana.Close()
+
+	env := remoteEnvironment(profile.RemoteConfig{
+		ElasticsearchURL: "http://elasticsearch.invalid",
+		KibanaURL:        kibana.URL + "/",
+		APIKey:           "Zm9vOmJhcg==",
+	})
+	require.Equal(t, kibana.URL, env[KibanaHostEnv])
+	require.Equal(t, profile.ProfileTypeRemote, env[StackTypeEnv])
+
+	token, err := fleetEnrollmentToken(env)
+	require.NoError(t, err)
+	require.Equal(t, "default-key", token)
+}
@@ -0,0 +1,134 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"path"
+)
+
+const (
+	// profileCertsDir is the directory with certificates of the profile, relative to the stack directory.
+	profileCertsDir = "../certs"
+
+	elasticsearchTLSDir = "/usr/share/elasticsearch/config/tls"
+	kibanaTLSDir        = "/usr/share/kibana/config/tls"
+	agentTLSDir         = "/etc/elastic-package/tls"
+
+	// agentSystemCAPath is the location of the CA certificate in the system trust store of Elastic Agents.
+	// It's used to verify certificates of outputs defined in Fleet.
+	agentSystemCAPath = "/etc/ssl/certs/elastic-package-ca.pem"
+
+	caCertificateFile = "ca-cert.pem"
+)
+
+// applyTLS configures services of the stack to use HTTPS, with certificates signed by the certificate authority
+// of the profile. It returns Kibana settings, which need to be applied to the Kibana configuration.
+func applyTLS(f *composeFile, nodes []string, agents []string) map[string]interface{} {
+	for _, node := range nodes {
+		service := f.Services[node]
+		service["environment"] = append(service["environment"].([]string), elasticsearchTLSEnv()...)
+		service["volumes"] = appendVolume(service["volumes"], tlsVolumes(elasticsearchTLSDir, "elasticsearch")...)
+		service["healthcheck"] = map[string]interface{}{
+			"test": []string{"CMD", "curl", "-f", "--cacert", path.Join(elasticsearchTLSDir, caCertificateFile),
+				"-u", "elastic:changeme", "https://127.0.0.1:9200/"},
+			"retries":  300,
+			"interval": "1s",
+		}
+	}
+
+	kibanaCA := path.Join(kibanaTLSDir, caCertificateFile)
+	f.Services["kibana"] = map[string]interface{}{
+		"volumes": tlsVolumes(kibanaTLSDir, "kibana"),
+		"healthcheck": map[string]interface{}{
+			"test": `curl -s -f --cacert ` + kibanaCA + ` https://127.0.0.1:5601/login | grep kbn-injected-metadata 2>&1 >/dev/null && ` +
+				`curl -s -f --cacert ` + kibanaCA + ` -u elastic:changeme "https://elasticsearch:9200/_cat/indices/.security-*?h=health" | grep -v red`,
+			"retries":  600,
+			"interval": "1s",
+		},
+	}
+
+	agentCA := path.Join(agentTLSDir, caCertificateFile)
+	f.Services["fleet-server"] = map[string]interface{}{
+		"environment": []string{
+			"FLEET_SERVER_INSECURE_HTTP=0",
+			"FLEET_SERVER_CERT=" + path.Join(agentTLSDir, "fleet-server", "cert.pem"),
+			"FLEET_SERVER_CERT_KEY=" + path.Join(agentTLSDir, "fleet-server", "key.pem"),
+			"FLEET_SERVER_ELASTICSEARCH_HOST=https://elasticsearch:9200",
+			"FLEET_SERVER_ELASTICSEARCH_CA=" + agentCA,
+			"ELASTICSEARCH_HOST=https://elasticsearch:9200",
+			"ELASTICSEARCH_CA=" + agentCA,
+			"KIBANA_FLEET_HOST=https://kibana:5601",
+			"KIBANA_FLEET_CA=" + agentCA,
+			"FLEET_CA=" + agentCA,
+		},
+		"volumes": append(tlsVolumes(agentTLSDir, "fleet-server"), agentSystemCAVolume()),
+		"healthcheck": map[string]interface{}{
+			"test":     "curl -f --cacert " + agentCA + " https://127.0.0.1:8220/api/status | grep HEALTHY 2>&1 >/dev/null",
+			"retries":  12,
+			"interval": "5s",
+		},
+	}
+
+	for _, agent := range append([]string{"elastic-agent"}, agents...) {
+		service, found := f.Services[agent]
+		if !found {
+			service = map[string]interface{}{}
+			f.Services[agent] = service
+		}
+		service["environment"] = []string{
+			"FLEET_INSECURE=0",
+			"FLEET_URL=https://fleet-server:8220",
+			"FLEET_CA=" + agentCA,
+		}
+		service["volumes"] = agentTLSVolumes()
+	}
+
+	return map[string]interface{}{
+		"server.ssl.enabled":                       true,
+		"server.ssl.certificate":                   path.Join(kibanaTLSDir, "kibana", "cert.pem"),
+		"server.ssl.key":                           path.Join(kibanaTLSDir, "kibana", "key.pem"),
+		"elasticsearch.hosts":                      []interface{}{"https://elasticsearch:9200"},
+		"elasticsearch.ssl.certificateAuthorities": []interface{}{kibanaCA},
+		"xpack.fleet.agents.elasticsearch.host":    "https://elasticsearch:9200",
+		"xpack.fleet.agents.fleet_server.hosts":    []interface{}{"https://fleet-server:8220"},
+	}
+}
+
+// elasticsearchTLSEnv returns settings enabling TLS on the HTTP layer of the Elasticsearch node.
+// Paths are relative to the Elasticsearch configuration directory.
+func elasticsearchTLSEnv() []string {
+	return []string{
+		"xpack.security.http.ssl.enabled=true",
+		"xpack.security.http.ssl.key=tls/elasticsearch/key.pem",
+		"xpack.security.http.ssl.certificate=tls/elasticsearch/cert.pem",
+		"xpack.security.http.ssl.certificate_authorities=tls/" + caCertificateFile,
+	}
+}
+
+// tlsVolumes returns volumes mounting the CA certificate and the certificate and key of the service into the TLS directory.
+// Certificates of other services aren't mounted.
+func tlsVolumes(tlsDir, service string) []string {
+	return []string{
+		path.Join(profileCertsDir, caCertificateFile) + ":" + path.Join(tlsDir, caCertificateFile) + ":ro",
+		path.Join(profileCertsDir, service) + ":" + path.Join(tlsDir, service) + ":ro",
+	}
+}
+
+// agentTLSVolumes returns volumes mounting only the CA certificate into Elastic Agents, as they don't serve HTTPS.
+func agentTLSVolumes() []string {
+	return []string{
+		path.Join(profileCertsDir, caCertificateFile) + ":" + path.Join(agentTLSDir, caCertificateFile) + ":ro",
+		agentSystemCAVolume(),
+	}
+}
+
+func agentSystemCAVolume() string {
+	return path.Join(profileCertsDir, caCertificateFile) + ":" + agentSystemCAPath + ":ro"
+}
+
+func appendVolume(volumes interface{}, volume ...string) []string {
+	list, _ := volumes.([]string)
+	return append(list, volume...)
+}
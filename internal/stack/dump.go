@@ -9,6 +9,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 
@@ -18,54 +21,269 @@ import (
 
 var observedServices = []string{"elasticsearch", "elastic-agent", "fleet-server", "kibana", "package-registry"}
 
+// DumpSection is a part of Elastic stack data, which can be dumped.
+type DumpSection string
+
+// Sections of Elastic stack data.
+const (
+	DumpSectionLogs            DumpSection = "logs"
+	DumpSectionClusterHealth   DumpSection = "cluster-health"
+	DumpSectionIndices         DumpSection = "indices"
+	DumpSectionDataStreams     DumpSection = "data-streams"
+	DumpSectionIndexTemplates  DumpSection = "index-templates"
+	DumpSectionIngestPipelines DumpSection = "ingest-pipelines"
+	DumpSectionAgentPolicies   DumpSection = "agent-policies"
+	DumpSectionAgents          DumpSection = "agents"
+	DumpSectionPackages        DumpSection = "packages"
+	DumpSectionRegistry        DumpSection = "registry"
+)
+
+// dumpErrorsFile lists sections, which couldn't be dumped.
+const dumpErrorsFile = "errors.txt"
+
 // DumpOptions defines dumping options for Elatic stack data.
 type DumpOptions struct {
 	Output  string
 	Profile *profile.Profile
+
+	// Sections selects the data to be dumped, all sections by default.
+	Sections []DumpSection
+
+	// Archive enables packing the dump into a single <output>.tar.gz archive.
+	Archive bool
+}
+
+// dumpSectionFunc collects data of the section and returns files to be stored in the output location,
+// indexed by paths relative to the output location.
+type dumpSectionFunc func(options DumpOptions, env map[string]string) (map[string][]byte, error)
+
+var dumpSections = map[DumpSection]dumpSectionFunc{
+	DumpSectionLogs:            dumpStackLogs,
+	DumpSectionClusterHealth:   elasticsearchDumpSection("cluster-health.json", "/_cluster/health"),
+	DumpSectionIndices:         elasticsearchDumpSection("indices.txt", "/_cat/indices?v&s=index&expand_wildcards=all"),
+	DumpSectionDataStreams:     elasticsearchDumpSection("data-streams.json", "/_data_stream"),
+	DumpSectionIndexTemplates:  elasticsearchDumpSection("index-templates.json", "/_index_template"),
+	DumpSectionIngestPipelines: elasticsearchDumpSection("ingest-pipelines.json", "/_ingest/pipeline"),
+	DumpSectionAgentPolicies:   kibanaDumpSection("fleet/agent-policies.json", "/api/fleet/agent_policies?perPage=1000&full=true"),
+	DumpSectionAgents:          dumpAgents,
+	DumpSectionPackages:        kibanaDumpSection("fleet/packages.json", "/api/fleet/epm/packages?experimental=true"),
+	DumpSectionRegistry:        dumpRegistrySearch,
+}
+
+// DumpSections function returns names of all available sections of Elastic stack data.
+func DumpSections() []DumpSection {
+	var sections []DumpSection
+	for section := range dumpSections {
+		sections = append(sections, section)
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		return sections[i] < sections[j]
+	})
+	return sections
 }
 
 // Dump function exports stack data and dumps them as local artifacts, which can be used for debug purposes.
+// Sections are collected in parallel. Failures of particular sections are logged and listed in the errors.txt file,
+// but they don't interrupt collecting remaining ones. It returns the path to the dump (directory or archive).
 func Dump(options DumpOptions) (string, error) {
 	logger.Debugf("Dump Elastic stack data")
 
-	err := dumpStackLogs(options)
-	if err != nil {
-		return "", errors.Wrap(err, "can't dump Elastic stack logs")
+	sections := options.Sections
+	if len(sections) == 0 {
+		sections = DumpSections()
+	}
+	for _, section := range sections {
+		if _, found := dumpSections[section]; !found {
+			return "", fmt.Errorf("unknown dump section: %s", section)
+		}
 	}
-	return options.Output, nil
-}
-
-func dumpStackLogs(options DumpOptions) error {
-	logger.Debugf("Dump stack logs")
 
 	logger.Debugf("Recreate the output location (path: %s)", options.Output)
 	err := os.RemoveAll(options.Output)
 	if err != nil {
-		return errors.Wrap(err, "can't remove output location")
+		return "", errors.Wrap(err, "can't remove output location")
+	}
+
+	err = os.MkdirAll(options.Output, 0755)
+	if err != nil {
+		return "", errors.Wrap(err, "can't create output location")
 	}
 
-	logsPath := filepath.Join(options.Output, "logs")
-	err = os.MkdirAll(logsPath, 0755)
+	env, err := dumpEnvironment(options.Profile)
 	if err != nil {
-		return errors.Wrap(err, "can't create output location")
+		logger.Warnf("can't read stack environment variables, sections using Elasticsearch and Kibana APIs will fail: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sectionErrors := map[DumpSection]error{}
+	for _, section := range sections {
+		wg.Add(1)
+		go func(section DumpSection) {
+			defer wg.Done()
+
+			err := dumpSection(options, env, section)
+			if err != nil {
+				logger.Errorf("can't dump Elastic stack data (section: %s): %v", section, err)
+
+				mu.Lock()
+				sectionErrors[section] = err
+				mu.Unlock()
+			}
+		}(section)
+	}
+	wg.Wait()
+
+	if len(sectionErrors) > 0 {
+		err = writeDumpErrors(options.Output, sectionErrors)
+		if err != nil {
+			return "", errors.Wrap(err, "can't write dump errors")
+		}
+	}
+
+	if !options.Archive {
+		return options.Output, nil
+	}
+
+	archivePath := strings.TrimSuffix(options.Output, string(filepath.Separator)) + ".tar.gz"
+	err = createTarGzArchive(options.Output, archivePath)
+	if err != nil {
+		return "", errors.Wrap(err, "can't archive the stack dump")
+	}
+	return archivePath, nil
+}
+
+func dumpSection(options DumpOptions, env map[string]string, section DumpSection) error {
+	logger.Debugf("Dump Elastic stack data (section: %s)", section)
+
+	files, err := dumpSections[section](options, env)
+	for path, body := range files {
+		path = filepath.Join(options.Output, path)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0755); mkdirErr != nil {
+			return errors.Wrapf(mkdirErr, "can't create directory (path: %s)", filepath.Dir(path))
+		}
+		if writeErr := ioutil.WriteFile(path, body, 0644); writeErr != nil {
+			return errors.Wrapf(writeErr, "can't write file (path: %s)", path)
+		}
+	}
+	return err
+}
+
+func writeDumpErrors(output string, sectionErrors map[DumpSection]error) error {
+	var lines []string
+	for section, err := range sectionErrors {
+		lines = append(lines, fmt.Sprintf("%s: %v", section, err))
 	}
+	sort.Strings(lines)
+	return ioutil.WriteFile(filepath.Join(output, dumpErrorsFile), []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
 
-	snapshotPath := options.Profile.FetchPath(profile.SnapshotFile)
+// dumpEnvironment returns environment variables describing the stack. Variables set in the current
+// environment (e.g. using "elastic-package stack shellinit") take precedence.
+func dumpEnvironment(elasticStackProfile *profile.Profile) (map[string]string, error) {
+	env := map[string]string{}
+	profileEnv, err := Environment(elasticStackProfile)
+	for k, v := range profileEnv {
+		env[k] = v
+	}
 
-	for _, serviceName := range observedServices {
+	for _, k := range []string{ElasticsearchHostEnv, ElasticsearchUsernameEnv, ElasticsearchPasswordEnv, KibanaHostEnv,
+		ElasticsearchAPIKeyEnv, CACertificateEnv, StackTypeEnv} {
+		if v, found := os.LookupEnv(k); found {
+			env[k] = v
+		}
+	}
+	return env, err
+}
+
+func dumpStackLogs(options DumpOptions, _ map[string]string) (map[string][]byte, error) {
+	logger.Debugf("Dump stack logs")
+
+	services, err := stackServices(options.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	var failedServices []string
+	for _, serviceName := range services {
 		logger.Debugf("Dump stack logs for %s", serviceName)
 
-		serviceLogs, err := dockerComposeLogs(serviceName, snapshotPath)
+		serviceLogs, err := dockerComposeLogs(serviceName, options.Profile)
 		if err != nil {
 			logger.Errorf("can't fetch service logs (service: %s): %v", serviceName, err)
+			failedServices = append(failedServices, serviceName)
 			continue
 		}
+		files[filepath.Join("logs", fmt.Sprintf("%s.log", serviceName))] = serviceLogs
+	}
+
+	if len(failedServices) > 0 {
+		return files, fmt.Errorf("can't fetch logs of services: %s", strings.Join(failedServices, ", "))
+	}
+	return files, nil
+}
+
+// stackServices returns services, which are started locally by the profile, including
+// additional Elasticsearch nodes, Elastic Agent replicas and side-cars.
+func stackServices(elasticStackProfile *profile.Profile) ([]string, error) {
+	config, err := elasticStackProfile.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read profile configuration")
+	}
+	if config.IsRemote() {
+		return []string{"elastic-agent"}, nil
+	}
+	services := append([]string{}, observedServices...)
+	return append(services, additionalServices(config.Stack)...), nil
+}
+
+func dumpAgents(_ DumpOptions, env map[string]string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	agents, err := kibanaGet(env, "/api/fleet/agents?perPage=1000&showInactive=true")
+	if err != nil {
+		return nil, err
+	}
+	files[filepath.Join("fleet", "agents.json")] = agents
+
+	status, err := kibanaGet(env, "/api/fleet/agent-status")
+	if err != nil {
+		return files, err
+	}
+	files[filepath.Join("fleet", "agent-status.json")] = status
+	return files, nil
+}
+
+func dumpRegistrySearch(options DumpOptions, _ map[string]string) (map[string][]byte, error) {
+	registryURL, err := packageRegistryURL(options.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := apiGet(registryURL, "/search?all=true&internal=true&experimental=true", apiAuth{})
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{filepath.Join("registry", "search.json"): body}, nil
+}
+
+func elasticsearchDumpSection(file, resourcePath string) dumpSectionFunc {
+	return func(_ DumpOptions, env map[string]string) (map[string][]byte, error) {
+		body, err := elasticsearchGet(env, resourcePath)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{filepath.Join("elasticsearch", file): body}, nil
+	}
+}
 
-		err = ioutil.WriteFile(filepath.Join(logsPath, fmt.Sprintf("%s.log", serviceName)), serviceLogs, 0644)
+func kibanaDumpSection(file, resourcePath string) dumpSectionFunc {
+	return func(_ DumpOptions, env map[string]string) (map[string][]byte, error) {
+		body, err := kibanaGet(env, resourcePath)
 		if err != nil {
-			logger.Errorf("can't write service logs (service: %s): %v", serviceName, err)
-			continue
+			return nil, err
 		}
+		return map[string][]byte{filepath.FromSlash(file): body}, nil
 	}
-	return nil
 }
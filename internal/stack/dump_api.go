@@ -0,0 +1,102 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const dumpRequestTimeout = 30 * time.Second
+
+func elasticsearchGet(env map[string]string, resourcePath string) ([]byte, error) {
+	host := env[ElasticsearchHostEnv]
+	if host == "" {
+		return nil, UndefinedEnvError(ElasticsearchHostEnv)
+	}
+	return apiGet(host, resourcePath, envAPIAuth(env))
+}
+
+func kibanaGet(env map[string]string, resourcePath string) ([]byte, error) {
+	host := env[KibanaHostEnv]
+	if host == "" {
+		return nil, UndefinedEnvError(KibanaHostEnv)
+	}
+	return apiGet(host, resourcePath, envAPIAuth(env))
+}
+
+// apiAuth defines credentials used to access stack APIs.
+type apiAuth struct {
+	username string
+	password string
+	apiKey   string
+
+	// caCertPath is the path to the certificate authority used to verify HTTPS servers.
+	caCertPath string
+}
+
+func envAPIAuth(env map[string]string) apiAuth {
+	return apiAuth{
+		username:   env[ElasticsearchUsernameEnv],
+		password:   env[ElasticsearchPasswordEnv],
+		apiKey:     env[ElasticsearchAPIKeyEnv],
+		caCertPath: env[CACertificateEnv],
+	}
+}
+
+// apiGet sends the GET request to the API and returns the response body. JSON responses are indented.
+func apiGet(host, resourcePath string, auth apiAuth) ([]byte, error) {
+	u := host + resourcePath
+	logger.Debugf("GET %s", u)
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create request (URL: %s)", u)
+	}
+	switch {
+	case auth.apiKey != "":
+		req.Header.Set("Authorization", "ApiKey "+auth.apiKey)
+	case auth.username != "":
+		req.SetBasicAuth(auth.username, auth.password)
+	}
+
+	transport, err := httpTransport(auth.caCertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	client := http.Client{Timeout: dumpRequestTimeout}
+	if transport != nil {
+		client.Transport = transport
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not send request (URL: %s)", u)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read response body (URL: %s)", u)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d (URL: %s, response body: %s)", resp.StatusCode, u, string(body))
+	}
+
+	var indented bytes.Buffer
+	if json.Indent(&indented, body, "", "  ") == nil {
+		return indented.Bytes(), nil
+	}
+	return body, nil
+}
@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// createTarGzArchive packs the content of the source directory into the gzipped tar archive. Entries
+// are stored under the directory named like the source directory.
+func createTarGzArchive(sourceDir, archivePath string) error {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return errors.Wrapf(err, "can't create archive (path: %s)", archivePath)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	baseDir := filepath.Base(filepath.Clean(sourceDir))
+	err = filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(baseDir, relPath))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "can't add files to archive (path: %s)", archivePath)
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return errors.Wrap(err, "can't close tar writer")
+	}
+	err = gw.Close()
+	if err != nil {
+		return errors.Wrap(err, "can't close gzip writer")
+	}
+	return f.Close()
+}
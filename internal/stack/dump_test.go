@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticsearchDumpSection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username != "elastic" || password != "changeme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/_cluster/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"status":"green"}`))
+	}))
+	defer server.Close()
+
+	env := map[string]string{
+		ElasticsearchHostEnv:     server.URL,
+		ElasticsearchUsernameEnv: "elastic",
+		ElasticsearchPasswordEnv: "changeme",
+	}
+
+	files, err := elasticsearchDumpSection("cluster-health.json", "/_cluster/health")(DumpOptions{}, env)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"status\": \"green\"\n}", string(files[filepath.Join("elasticsearch", "cluster-health.json")]))
+
+	_, err = elasticsearchDumpSection("indices.txt", "/_cat/indices")(DumpOptions{}, env)
+	require.Error(t, err)
+
+	_, err = elasticsearchDumpSection("indices.txt", "/_cat/indices")(DumpOptions{}, map[string]string{})
+	require.Error(t, err)
+}
+
+func TestCreateTarGzArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dump-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "stack")
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "logs"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(source, "logs", "kibana.log"), []byte("kibana logs"), 0644))
+
+	archivePath := filepath.Join(dir, "stack.tar.gz")
+	require.NoError(t, createTarGzArchive(source, archivePath))
+
+	f, err := os.Open(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+
+	contents := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		body, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		contents[header.Name] = string(body)
+	}
+	require.Equal(t, map[string]string{
+		"stack/":                "",
+		"stack/logs/":           "",
+		"stack/logs/kibana.log": "kibana logs",
+	}, contents)
+}
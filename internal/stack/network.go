@@ -18,6 +18,11 @@ func EnsureStackNetworkUp() error {
 	return errors.Wrap(err, "network not available")
 }
 
+// ServiceContainerName function returns the name of the container running the stack service.
+func ServiceContainerName(serviceName string) string {
+	return fmt.Sprintf("%s_%s_1", DockerComposeProjectName, serviceName)
+}
+
 // Network function returns the stack network name.
 func Network() string {
 	return fmt.Sprintf("%s_default", DockerComposeProjectName)
@@ -7,6 +7,7 @@ package stack
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
@@ -25,50 +26,130 @@ var (
 	ElasticsearchUsernameEnv = elasticPackageEnvPrefix + "ELASTICSEARCH_USERNAME"
 	ElasticsearchPasswordEnv = elasticPackageEnvPrefix + "ELASTICSEARCH_PASSWORD"
 	KibanaHostEnv            = elasticPackageEnvPrefix + "KIBANA_HOST"
+	CACertificateEnv         = elasticPackageEnvPrefix + "CA_CERT"
+	ElasticsearchAPIKeyEnv   = elasticPackageEnvPrefix + "ELASTICSEARCH_API_KEY"
+	StackTypeEnv             = elasticPackageEnvPrefix + "STACK_TYPE"
+
+	// ProfileNameEnv selects the profile used by elastic-package commands.
+	ProfileNameEnv = elasticPackageEnvPrefix + "PROFILE"
 )
 
-var shellInitFormat = "export " + ElasticsearchHostEnv + "=%s\nexport " + ElasticsearchUsernameEnv + "=%s\nexport " +
-	ElasticsearchPasswordEnv + "=%s\nexport " + KibanaHostEnv + "=%s"
+// shellInitVariables lists variables exposed by ShellInit. Optional variables, which don't apply to the profile,
+// are unset, so values of previously loaded profiles don't persist.
+var shellInitVariables = []struct {
+	name     string
+	optional bool
+}{
+	{name: ElasticsearchHostEnv},
+	{name: ElasticsearchUsernameEnv},
+	{name: ElasticsearchPasswordEnv},
+	{name: KibanaHostEnv},
+	{name: ElasticsearchAPIKeyEnv, optional: true},
+	{name: CACertificateEnv, optional: true},
+	{name: StackTypeEnv, optional: true},
+}
 
 type kibanaConfiguration struct {
 	ElasticsearchUsername string `yaml:"elasticsearch.username"`
 	ElasticsearchPassword string `yaml:"elasticsearch.password"`
 }
 
-// ShellInit method exposes environment variables that can be used for testing purposes.
+// ShellInit method exposes environment variables that can be used for testing purposes. The name of the profile
+// is exposed too, so following commands use the same profile.
 func ShellInit(elasticStackProfile *profile.Profile) (string, error) {
+	env, err := Environment(elasticStackProfile)
+	if err != nil {
+		return "", err
+	}
+
+	return shellInitScript(elasticStackProfile.Name(), env), nil
+}
+
+func shellInitScript(profileName string, env map[string]string) string {
+	lines := []string{fmt.Sprintf("export %s=%s", ProfileNameEnv, profileName)}
+	for _, v := range shellInitVariables {
+		value, found := env[v.name]
+		if !found && v.optional {
+			lines = append(lines, fmt.Sprintf("unset %s", v.name))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("export %s=%s", v.name, value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Environment function returns environment variables describing the stack booted up using the profile.
+// If TLS is enabled in the profile, it includes also the path to the certificate authority of the profile.
+// Remote profiles describe endpoints and credentials of the remote stack.
+func Environment(elasticStackProfile *profile.Profile) (map[string]string, error) {
+	config, err := elasticStackProfile.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read profile configuration")
+	}
+
+	if config.IsRemote() {
+		return remoteEnvironment(config.Remote), nil
+	}
+
 	// Read Elasticsearch username and password from Kibana configuration file.
 	body, err := ioutil.ReadFile(elasticStackProfile.FetchPath(profile.KibanaConfigFile))
 	if err != nil {
-		return "", errors.Wrap(err, "error reading Kibana config file")
+		return nil, errors.Wrap(err, "error reading Kibana config file")
 	}
 
 	var kibanaCfg kibanaConfiguration
 	err = yaml.Unmarshal(body, &kibanaCfg)
 	if err != nil {
-		return "", errors.Wrap(err, "unmarshalling Kibana configuration failed")
+		return nil, errors.Wrap(err, "unmarshalling Kibana configuration failed")
 	}
 
 	// Read Elasticsearch and Kibana hostnames from Elastic Stack Docker Compose configuration file.
-	p, err := compose.NewProject(DockerComposeProjectName, elasticStackProfile.FetchPath(profile.SnapshotFile))
+	p, err := newComposeProject(elasticStackProfile)
 	if err != nil {
-		return "", errors.Wrap(err, "could not create docker compose project")
+		return nil, err
 	}
 
 	serviceComposeConfig, err := p.Config(compose.CommandOptions{})
 	if err != nil {
-		return "", errors.Wrap(err, "could not get Docker Compose configuration for service")
+		return nil, errors.Wrap(err, "could not get Docker Compose configuration for service")
+	}
+
+	scheme := "http"
+	if config.Stack.TLS.Enabled {
+		scheme = "https"
 	}
 
 	kib := serviceComposeConfig.Services["kibana"]
-	kibHostPort := fmt.Sprintf("http://%s:%d", kib.Ports[0].ExternalIP, kib.Ports[0].ExternalPort)
+	kibHostPort := fmt.Sprintf("%s://%s:%d", scheme, kib.Ports[0].ExternalIP, kib.Ports[0].ExternalPort)
 
 	es := serviceComposeConfig.Services["elasticsearch"]
-	esHostPort := fmt.Sprintf("http://%s:%d", es.Ports[0].ExternalIP, es.Ports[0].ExternalPort)
+	esHostPort := fmt.Sprintf("%s://%s:%d", scheme, es.Ports[0].ExternalIP, es.Ports[0].ExternalPort)
+
+	env := map[string]string{
+		ElasticsearchHostEnv:     esHostPort,
+		ElasticsearchUsernameEnv: kibanaCfg.ElasticsearchUsername,
+		ElasticsearchPasswordEnv: kibanaCfg.ElasticsearchPassword,
+		KibanaHostEnv:            kibHostPort,
+	}
+	if config.Stack.TLS.Enabled {
+		env[CACertificateEnv] = elasticStackProfile.CACertificatePath()
+	}
+	return env, nil
+}
 
-	return fmt.Sprintf(shellInitFormat,
-		esHostPort,
-		kibanaCfg.ElasticsearchUsername,
-		kibanaCfg.ElasticsearchPassword,
-		kibHostPort), nil
+func remoteEnvironment(remote profile.RemoteConfig) map[string]string {
+	env := map[string]string{
+		ElasticsearchHostEnv:     strings.TrimSuffix(remote.ElasticsearchURL, "/"),
+		ElasticsearchUsernameEnv: remote.Username,
+		ElasticsearchPasswordEnv: remote.Password,
+		KibanaHostEnv:            strings.TrimSuffix(remote.KibanaURL, "/"),
+		StackTypeEnv:             profile.ProfileTypeRemote,
+	}
+	if remote.APIKey != "" {
+		env[ElasticsearchAPIKeyEnv] = remote.APIKey
+	}
+	if remote.CACert != "" {
+		env[CACertificateEnv] = remote.CACert
+	}
+	return env
 }
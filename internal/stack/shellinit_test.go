@@ -0,0 +1,31 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellInitScript(t *testing.T) {
+	script := shellInitScript("default", map[string]string{
+		ElasticsearchHostEnv:     "https://127.0.0.1:9200",
+		ElasticsearchUsernameEnv: "elastic",
+		ElasticsearchPasswordEnv: "changeme",
+		KibanaHostEnv:            "https://127.0.0.1:5601",
+		CACertificateEnv:         "/home/user/.elastic-package/profiles/default/certs/ca-cert.pem",
+	})
+
+	// Optional variables of other profiles, e.g. remote ones, are unset.
+	require.Equal(t, `export ELASTIC_PACKAGE_PROFILE=default
+export ELASTIC_PACKAGE_ELASTICSEARCH_HOST=https://127.0.0.1:9200
+export ELASTIC_PACKAGE_ELASTICSEARCH_USERNAME=elastic
+export ELASTIC_PACKAGE_ELASTICSEARCH_PASSWORD=changeme
+export ELASTIC_PACKAGE_KIBANA_HOST=https://127.0.0.1:5601
+unset ELASTIC_PACKAGE_ELASTICSEARCH_API_KEY
+export ELASTIC_PACKAGE_CA_CERT=/home/user/.elastic-package/profiles/default/certs/ca-cert.pem
+unset ELASTIC_PACKAGE_STACK_TYPE`, script)
+}
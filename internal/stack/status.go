@@ -0,0 +1,182 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/compose"
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+const (
+	composeServiceLabel  = "com.docker.compose.service"
+	isReadyServiceSuffix = "_is_ready"
+
+	registryHealthTimeout = 10 * time.Second
+)
+
+// Container states and health statuses reported for stack services.
+const (
+	ServiceStateNotCreated = "not created"
+	ServiceHealthHealthy   = "healthy"
+)
+
+// imageVersionLabels are labels of Docker images, which may contain the version of the service.
+var imageVersionLabels = []string{"org.opencontainers.image.version", "org.label-schema.version"}
+
+// ServiceStatus describes the container of the Elastic stack service.
+type ServiceStatus struct {
+	Name    string
+	State   string
+	Health  string // empty if the service doesn't define a health check
+	Image   string
+	Version string
+}
+
+// Healthy method returns true if the service container is running and healthy (if it defines a health check).
+func (s ServiceStatus) Healthy() bool {
+	return s.State == "running" && (s.Health == "" || s.Health == ServiceHealthHealthy)
+}
+
+// Status function returns statuses of Elastic stack services defined in the snapshot file of the profile.
+// Auxiliary services (used to wait for other services to be ready) are omitted.
+func Status(options Options) ([]ServiceStatus, error) {
+	p, err := newComposeProject(options.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := compose.CommandOptions{Env: options.Profile.ComposeEnvVars()}
+	config, err := p.Config(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get Docker Compose configuration for the stack")
+	}
+
+	containerIDs, err := p.ContainerIDs(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list containers of the stack")
+	}
+
+	containers := map[string]docker.ContainerDescription{}
+	if len(containerIDs) > 0 {
+		descriptions, err := docker.InspectContainers(containerIDs...)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not inspect containers of the stack")
+		}
+		for _, d := range descriptions {
+			containers[d.Config.Labels[composeServiceLabel]] = d
+		}
+	}
+
+	var statuses []ServiceStatus
+	for name := range config.Services {
+		if strings.HasSuffix(name, isReadyServiceSuffix) {
+			continue
+		}
+
+		d, found := containers[name]
+		if !found {
+			statuses = append(statuses, ServiceStatus{Name: name, State: ServiceStateNotCreated})
+			continue
+		}
+		statuses = append(statuses, newServiceStatus(name, d))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses, nil
+}
+
+func newServiceStatus(name string, d docker.ContainerDescription) ServiceStatus {
+	status := ServiceStatus{
+		Name:    name,
+		State:   d.State.Status,
+		Image:   d.Config.Image,
+		Version: imageVersion(d.Config.Image, d.Config.Labels),
+	}
+	if d.State.Health != nil {
+		status.Health = d.State.Health.Status
+	}
+	return status
+}
+
+// imageVersion returns the version of the service based on image labels, or the image tag if labels are not defined.
+func imageVersion(image string, labels map[string]string) string {
+	for _, label := range imageVersionLabels {
+		if v, found := labels[label]; found && v != "" {
+			return v
+		}
+	}
+
+	// Tag is the part after the last colon, unless the colon belongs to the registry host (e.g. localhost:5000/image).
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	return image[i+1:]
+}
+
+// PackageRegistryHealth function checks the health endpoint of the package registry started in the Elastic stack.
+func PackageRegistryHealth(elasticStackProfile *profile.Profile) error {
+	registryURL, err := packageRegistryURL(elasticStackProfile)
+	if err != nil {
+		return err
+	}
+	healthURL := registryURL + "/health"
+
+	client := http.Client{Timeout: registryHealthTimeout}
+	resp, err := client.Get(healthURL)
+	if err != nil {
+		return errors.Wrapf(err, "could not reach package registry (URL: %s)", healthURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("package registry is not healthy; status code = %d; response body = %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// packageRegistryURL returns the URL of the package registry started in the Elastic stack, as exposed on the host.
+func packageRegistryURL(elasticStackProfile *profile.Profile) (string, error) {
+	stackConfig, err := elasticStackProfile.Config()
+	if err != nil {
+		return "", errors.Wrap(err, "can't read profile configuration")
+	}
+	if stackConfig.IsRemote() {
+		return "", errors.New("package registry isn't started for remote stacks")
+	}
+
+	p, err := newComposeProject(elasticStackProfile)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := p.Config(compose.CommandOptions{Env: elasticStackProfile.ComposeEnvVars()})
+	if err != nil {
+		return "", errors.Wrap(err, "could not get Docker Compose configuration for the stack")
+	}
+
+	registry, found := config.Services["package-registry"]
+	if !found || len(registry.Ports) == 0 {
+		return "", errors.New("package registry doesn't expose any ports")
+	}
+
+	host := registry.Ports[0].ExternalIP
+	if host == "" || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("http://%s:%d", host, registry.Ports[0].ExternalPort), nil
+}
@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageVersion(t *testing.T) {
+	cases := []struct {
+		image    string
+		labels   map[string]string
+		expected string
+	}{
+		{"docker.elastic.co/elasticsearch/elasticsearch:7.15.0-SNAPSHOT", nil, "7.15.0-SNAPSHOT"},
+		{"docker.elastic.co/beats/elastic-agent:7.15.0", map[string]string{"org.label-schema.version": "7.15.1"}, "7.15.1"},
+		{"localhost:5000/package-registry", nil, ""},
+		{"elastic-package-stack_package-registry", nil, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			require.Equal(t, c.expected, imageVersion(c.image, c.labels))
+		})
+	}
+}
+
+func TestServiceStatusHealthy(t *testing.T) {
+	require.True(t, ServiceStatus{State: "running"}.Healthy())
+	require.True(t, ServiceStatus{State: "running", Health: ServiceHealthHealthy}.Healthy())
+	require.False(t, ServiceStatus{State: "running", Health: "starting"}.Healthy())
+	require.False(t, ServiceStatus{State: ServiceStateNotCreated}.Healthy())
+}
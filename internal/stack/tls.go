@@ -0,0 +1,47 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// TLSConfig function returns the TLS configuration trusting the certificate authority stored in the file.
+// It returns nil if the path is empty, so the system trust store is used.
+func TLSConfig(caCertPath string) (*tls.Config, error) {
+	if caCertPath == "" {
+		return nil, nil
+	}
+
+	caCert, err := ioutil.ReadFile(caCertPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read CA certificate (path: %s)", caCertPath)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no valid certificates found (path: %s)", caCertPath)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// httpTransport function returns the HTTP transport trusting the certificate authority stored in the file,
+// or nil if the path is empty.
+func httpTransport(caCertPath string) (http.RoundTripper, error) {
+	tlsConfig, err := TLSConfig(caCertPath)
+	if err != nil || tlsConfig == nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
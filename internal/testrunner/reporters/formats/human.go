@@ -27,8 +27,20 @@ func reportHumanFormat(results []testrunner.TestResult) (string, error) {
 		return "No test results", nil
 	}
 
+	var withStackVersions bool
+	for _, r := range results {
+		if r.StackVersion != "" {
+			withStackVersions = true
+			break
+		}
+	}
+
 	t := table.NewWriter()
-	t.AppendHeader(table.Row{"Package", "Data stream", "Test type", "Test name", "Result", "Time elapsed"})
+	if withStackVersions {
+		t.AppendHeader(table.Row{"Stack version", "Package", "Data stream", "Test type", "Test name", "Result", "Time elapsed"})
+	} else {
+		t.AppendHeader(table.Row{"Package", "Data stream", "Test type", "Test name", "Result", "Time elapsed"})
+	}
 
 	for _, r := range results {
 		var result string
@@ -42,7 +54,11 @@ func reportHumanFormat(results []testrunner.TestResult) (string, error) {
 			result = "PASS"
 		}
 
-		t.AppendRow(table.Row{r.Package, r.DataStream, r.TestType, r.Name, result, r.TimeElapsed})
+		if withStackVersions {
+			t.AppendRow(table.Row{r.StackVersion, r.Package, r.DataStream, r.TestType, r.Name, result, r.TimeElapsed})
+		} else {
+			t.AppendRow(table.Row{r.Package, r.DataStream, r.TestType, r.Name, result, r.TimeElapsed})
+		}
 	}
 
 	t.SetStyle(table.StyleRounded)
@@ -55,7 +71,7 @@ func reportHumanFormat(results []testrunner.TestResult) (string, error) {
 			continue
 		}
 
-		detail := fmt.Sprintf("%s/%s %s:\n%s", r.Package, r.DataStream, r.Name, r.FailureDetails)
+		detail := fmt.Sprintf("%s:\n%s", testCaseLabel(r), r.FailureDetails)
 		details = append(details, detail)
 	}
 
@@ -63,5 +79,26 @@ func reportHumanFormat(results []testrunner.TestResult) (string, error) {
 		s += "\n\nFAILURE DETAILS:\n\n" + strings.Join(details, "\n")
 	}
 
+	var diagnostics []string
+	for _, r := range results {
+		if r.DiagnosticsPath == "" {
+			continue
+		}
+
+		diagnostics = append(diagnostics, fmt.Sprintf("%s: %s", testCaseLabel(r), r.DiagnosticsPath))
+	}
+
+	if len(diagnostics) > 0 {
+		s += "\n\nDIAGNOSTICS:\n\n" + strings.Join(diagnostics, "\n")
+	}
+
 	return s, nil
 }
+
+func testCaseLabel(r testrunner.TestResult) string {
+	label := fmt.Sprintf("%s/%s %s", r.Package, r.DataStream, r.Name)
+	if r.StackVersion != "" {
+		label += fmt.Sprintf(" (stack %s)", r.StackVersion)
+	}
+	return label
+}
@@ -7,6 +7,7 @@ package formats
 import (
 	"encoding/xml"
 	"fmt"
+	"sort"
 
 	"github.com/pkg/errors"
 
@@ -43,9 +44,10 @@ type testCase struct {
 	ClassName     string  `xml:"classname,attr"`
 	TimeInSeconds float64 `xml:"time,attr"`
 
-	Error   string   `xml:"error,omitempty"`
-	Failure string   `xml:"failure,omitempty"`
-	Skipped *skipped `xml:"skipped,omitempty"`
+	Error     string   `xml:"error,omitempty"`
+	Failure   string   `xml:"failure,omitempty"`
+	Skipped   *skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
 }
 
 type skipped struct {
@@ -53,28 +55,29 @@ type skipped struct {
 }
 
 func reportXUnitFormat(results []testrunner.TestResult) (string, error) {
-	// test type => package => data stream => test cases
-	tests := map[string]map[string]map[string][]testCase{}
+	// test suite (test type, stack version) => test cases
+	suites := map[string]*testSuite{}
 
-	var numTests, numFailures, numErrors, numSkipped int
 	for _, r := range results {
-		testType := string(r.TestType)
-		if _, exists := tests[testType]; !exists {
-			tests[testType] = map[string]map[string][]testCase{}
+		suiteName := string(r.TestType)
+		if r.StackVersion != "" {
+			suiteName = fmt.Sprintf("%s (stack %s)", r.TestType, r.StackVersion)
 		}
 
-		if _, exists := tests[testType][r.Package]; !exists {
-			tests[testType][r.Package] = map[string][]testCase{}
-		}
-
-		if _, exists := tests[testType][r.Package][r.DataStream]; !exists {
-			tests[testType][r.Package][r.DataStream] = make([]testCase, 0)
+		suite, exists := suites[suiteName]
+		if !exists {
+			suite = &testSuite{
+				Comment: fmt.Sprintf("test suite for %s tests", suiteName),
+				Name:    suiteName,
+				Cases:   make([]testCase, 0),
+			}
+			suites[suiteName] = suite
 		}
 
 		var failure string
 		if r.FailureMsg != "" {
 			failure = r.FailureMsg
-			numFailures++
+			suite.NumFailures++
 		}
 
 		if r.FailureDetails != "" {
@@ -82,11 +85,11 @@ func reportXUnitFormat(results []testrunner.TestResult) (string, error) {
 		}
 
 		if r.ErrorMsg != "" {
-			numErrors++
+			suite.NumErrors++
 		}
 
 		if r.Skipped != nil {
-			numSkipped++
+			suite.NumSkipped++
 		}
 
 		name := fmt.Sprintf("%s test", r.TestType)
@@ -106,34 +109,25 @@ func reportXUnitFormat(results []testrunner.TestResult) (string, error) {
 			c.Skipped = &skipped{r.Skipped.String()}
 		}
 
-		numTests++
+		if r.DiagnosticsPath != "" {
+			// Attachment reference recognized by the JUnit Attachments plugin in Jenkins.
+			c.SystemOut = fmt.Sprintf("[[ATTACHMENT|%s]]", r.DiagnosticsPath)
+		}
 
-		tests[testType][r.Package][r.DataStream] = append(tests[testType][r.Package][r.DataStream], c)
+		suite.NumTests++
+		suite.Cases = append(suite.Cases, c)
 	}
 
+	var suiteNames []string
+	for name := range suites {
+		suiteNames = append(suiteNames, name)
+	}
+	sort.Strings(suiteNames)
+
 	var ts testSuites
 	ts.Suites = make([]testSuite, 0)
-
-	for testType, packages := range tests {
-		testTypeSuite := testSuite{
-			Comment: fmt.Sprintf("test suite for %s tests", testType),
-			Name:    testType,
-
-			NumTests:    numTests,
-			NumFailures: numFailures,
-			NumErrors:   numErrors,
-			NumSkipped:  numSkipped,
-
-			Cases: make([]testCase, 0),
-		}
-
-		for _, pkg := range packages {
-			for _, ds := range pkg {
-				testTypeSuite.Cases = append(testTypeSuite.Cases, ds...)
-			}
-		}
-
-		ts.Suites = append(ts.Suites, testTypeSuite)
+	for _, name := range suiteNames {
+		ts.Suites = append(ts.Suites, *suites[name])
 	}
 
 	out, err := xml.MarshalIndent(&ts, "", "  ")
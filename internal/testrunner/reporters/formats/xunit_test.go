@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formats
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+func TestReportXUnitFormat_StackVersions(t *testing.T) {
+	results := []testrunner.TestResult{
+		{Package: "apache", DataStream: "access", TestType: "system", StackVersion: "7.15.0", Name: "default"},
+		{Package: "apache", DataStream: "error", TestType: "system", StackVersion: "7.15.0", Name: "default", FailureMsg: "no hits"},
+		{Package: "apache", DataStream: "access", TestType: "system", StackVersion: "7.14.0", Name: "default", ErrorMsg: "can't install"},
+	}
+
+	report, err := reportXUnitFormat(results)
+	require.NoError(t, err)
+
+	var ts testSuites
+	err = xml.Unmarshal([]byte(report), &ts)
+	require.NoError(t, err)
+
+	require.Len(t, ts.Suites, 2)
+	require.Equal(t, "system (stack 7.14.0)", ts.Suites[0].Name)
+	require.Equal(t, 1, ts.Suites[0].NumTests)
+	require.Equal(t, 1, ts.Suites[0].NumErrors)
+	require.Equal(t, "system (stack 7.15.0)", ts.Suites[1].Name)
+	require.Equal(t, 2, ts.Suites[1].NumTests)
+	require.Equal(t, 1, ts.Suites[1].NumFailures)
+}
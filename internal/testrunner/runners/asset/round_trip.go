@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package asset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/export"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// roundTripIgnoredFields are fields of saved objects, which are updated by Kibana when objects are loaded,
+// so they're not compared.
+var roundTripIgnoredFields = []string{
+	"coreMigrationVersion",
+	"migrationVersion",
+}
+
+// verifyDashboardRoundTrip exports the installed dashboard with referenced objects, applies the same transformations
+// as the "export dashboards" command, and compares results with objects stored in the package. It returns
+// semantic differences of objects, with encoded fields decoded.
+func verifyDashboardRoundTrip(kibanaClient *kibana.Client, packageRootPath, packageName, dashboardID string) ([]string, error) {
+	exported, err := kibanaClient.Export([]string{dashboardID})
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't export dashboard %s", dashboardID)
+	}
+
+	exported, err = export.TransformObjects(packageName, exported)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't transform exported objects")
+	}
+
+	var differences []string
+	for _, actual := range exported {
+		objectType, _ := actual.GetValue("type")
+		id, _ := actual.GetValue("id")
+		objectPath := export.ObjectPath(packageRootPath, objectType.(string), id.(string))
+
+		expected, err := readPackageObject(objectPath)
+		if os.IsNotExist(errors.Cause(err)) {
+			// Referenced objects may be provided by Kibana or other packages.
+			logger.Debugf("%s %s not found in the package, skipping", objectType, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		expected, err = normalizeObject(packageName, expected)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't normalize object (path: %s)", objectPath)
+		}
+
+		diff := compareObjects(expected, actual)
+		if diff != "" {
+			rel, _ := filepath.Rel(packageRootPath, objectPath)
+			differences = append(differences, fmt.Sprintf("%s (%s %s):\n%s", rel, objectType, id, diff))
+		}
+	}
+	return differences, nil
+}
+
+func readPackageObject(objectPath string) (common.MapStr, error) {
+	body, err := ioutil.ReadFile(objectPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read object (path: %s)", objectPath)
+	}
+
+	var object common.MapStr
+	err = json.Unmarshal(body, &object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't unmarshal object (path: %s)", objectPath)
+	}
+	return object, nil
+}
+
+// normalizeObject applies export transformations to the object stored in the package, so encoded fields
+// are decoded in the same way as in exported objects.
+func normalizeObject(packageName string, object common.MapStr) (common.MapStr, error) {
+	objects, err := export.TransformObjects(packageName, []common.MapStr{object})
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("object has been filtered out")
+	}
+	return objects[0], nil
+}
+
+// compareObjects returns semantic differences between objects, ignoring fields updated by Kibana.
+func compareObjects(expected, actual common.MapStr) string {
+	for _, field := range roundTripIgnoredFields {
+		_ = expected.Delete(field)
+		_ = actual.Delete(field)
+	}
+	return strings.TrimSpace(pretty.Compare(map[string]interface{}(expected), map[string]interface{}(actual)))
+}
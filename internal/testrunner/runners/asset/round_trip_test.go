@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package asset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/export"
+)
+
+func TestCompareObjects(t *testing.T) {
+	stored := func() common.MapStr {
+		return common.MapStr{
+			"id":   "nginx-overview",
+			"type": "dashboard",
+			"attributes": map[string]interface{}{
+				"title":      "[Logs Nginx] Overview",
+				"panelsJSON": []interface{}{map[string]interface{}{"panelIndex": "1", "gridData": map[string]interface{}{"w": 24.0}}},
+			},
+			"migrationVersion": map[string]interface{}{"dashboard": "7.9.3"},
+			"references":       []interface{}{},
+		}
+	}
+	exported := func(panelsJSON string) common.MapStr {
+		return common.MapStr{
+			"id":   "nginx-overview",
+			"type": "dashboard",
+			"attributes": map[string]interface{}{
+				"title":      "[Logs Nginx] Overview",
+				"panelsJSON": panelsJSON,
+			},
+			"migrationVersion": map[string]interface{}{"dashboard": "7.14.0"},
+			"references":       []interface{}{},
+			"updated_at":       "2021-10-01T10:00:00.000Z",
+			"version":          "WzEsMV0=",
+		}
+	}
+
+	transform := func(object common.MapStr) common.MapStr {
+		objects, err := export.TransformObjects("nginx", []common.MapStr{object})
+		require.NoError(t, err)
+		require.Len(t, objects, 1)
+		return objects[0]
+	}
+
+	expected, err := normalizeObject("nginx", stored())
+	require.NoError(t, err)
+	actual := transform(exported(`[{"gridData":{"w":24},"panelIndex":"1"}]`))
+	assert.Empty(t, compareObjects(expected, actual))
+
+	expected, err = normalizeObject("nginx", stored())
+	require.NoError(t, err)
+	actual = transform(exported(`[{"gridData":{"w":48},"panelIndex":"1"}]`))
+	diff := compareObjects(expected, actual)
+	assert.Contains(t, diff, "-")
+	assert.Contains(t, diff, "48")
+}
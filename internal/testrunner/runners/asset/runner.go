@@ -6,14 +6,17 @@ package asset
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	es "github.com/elastic/go-elasticsearch/v7"
 	"github.com/pkg/errors"
 
+	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/packages/installer"
+	"github.com/elastic/elastic-package/internal/profile"
 	"github.com/elastic/elastic-package/internal/testrunner"
 )
 
@@ -30,6 +33,7 @@ type runner struct {
 	testFolder      testrunner.TestFolder
 	packageRootPath string
 	esClient        *es.Client
+	profile         *profile.Profile
 
 	// Execution order of following handlers is defined in runner.tearDown() method.
 	removePackageHandler func() error
@@ -56,6 +60,7 @@ func (r runner) Run(options testrunner.TestOptions) ([]testrunner.TestResult, er
 	r.testFolder = options.TestFolder
 	r.packageRootPath = options.PackageRootPath
 	r.esClient = options.ESClient
+	r.profile = options.Profile
 
 	return r.run()
 }
@@ -66,7 +71,13 @@ func (r *runner) run() ([]testrunner.TestResult, error) {
 		Package:  r.testFolder.Package,
 	})
 
-	testConfig, err := newConfig(r.testFolder.Path)
+	// Asset loading tests are defined at the package level, so the test folder is optional.
+	testFolderPath := r.testFolder.Path
+	if testFolderPath == "" {
+		testFolderPath = filepath.Join(r.packageRootPath, "_dev", "test", string(TestType))
+	}
+
+	testConfig, err := newConfig(testFolderPath)
 	if err != nil {
 		return result.WithError(errors.Wrap(err, "unable to load asset loading test config file"))
 
@@ -85,7 +96,7 @@ func (r *runner) run() ([]testrunner.TestResult, error) {
 		return result.WithError(errors.Wrapf(err, "reading package manifest failed (path: %s)", r.packageRootPath))
 	}
 
-	packageInstaller, err := installer.CreateForManifest(*manifest)
+	packageInstaller, err := installer.CreateForManifest(*manifest, r.profile)
 	if err != nil {
 		return result.WithError(errors.Wrap(err, "can't create the package installer"))
 	}
@@ -129,6 +140,52 @@ func (r *runner) run() ([]testrunner.TestResult, error) {
 		results = append(results, r[0])
 	}
 
+	if testConfig == nil || !testConfig.RoundTrip {
+		logger.Debug("dashboard round-trip verification is not enabled")
+		return results, nil
+	}
+
+	roundTripResults, err := r.verifyDashboards(installedPackage.Manifest.Name, expectedAssets)
+	if err != nil {
+		return result.WithError(err)
+	}
+	return append(results, roundTripResults...), nil
+}
+
+// verifyDashboards checks if dashboards loaded by Kibana, once exported again, are equal to ones stored in the package.
+func (r *runner) verifyDashboards(packageName string, expectedAssets []packages.Asset) ([]testrunner.TestResult, error) {
+	kibanaClient, err := kibana.NewClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't create Kibana client")
+	}
+
+	var results []testrunner.TestResult
+	for _, e := range expectedAssets {
+		if e.Type != packages.AssetTypeKibanaDashboard {
+			continue
+		}
+
+		rc := testrunner.NewResultComposer(testrunner.TestResult{
+			Name:     fmt.Sprintf("%s %s round-trip", e.Type, e.ID),
+			Package:  packageName,
+			TestType: TestType,
+		})
+
+		var tr []testrunner.TestResult
+		differences, err := verifyDashboardRoundTrip(kibanaClient, r.packageRootPath, packageName, e.ID)
+		switch {
+		case err != nil:
+			tr, _ = rc.WithError(err)
+		case len(differences) > 0:
+			tr, _ = rc.WithError(testrunner.ErrTestCaseFailed{
+				Reason:  "dashboard exported from Kibana differs from the package",
+				Details: strings.Join(differences, "\n"),
+			})
+		default:
+			tr, _ = rc.WithSuccess()
+		}
+		results = append(results, tr[0])
+	}
 	return results, nil
 }
 
@@ -146,6 +203,11 @@ func (r *runner) TestFolderRequired() bool {
 	return false
 }
 
+// StackRequired returns whether the test runner needs a running Elastic stack.
+func (r *runner) StackRequired() bool {
+	return true
+}
+
 func findActualAsset(actualAssets []packages.Asset, expectedAsset packages.Asset) bool {
 	for _, a := range actualAssets {
 		if a.Type == expectedAsset.Type && a.ID == expectedAsset.ID {
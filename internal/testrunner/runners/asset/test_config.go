@@ -18,6 +18,9 @@ import (
 
 type testConfig struct {
 	testrunner.SkippableConfig `config:",inline"`
+
+	// RoundTrip enables verification of dashboards exported again from Kibana after installing the package.
+	RoundTrip bool `config:"round_trip"`
 }
 
 func newConfig(assetTestFolderPath string) (*testConfig, error) {
@@ -36,6 +36,11 @@ func (r *runner) TestFolderRequired() bool {
 	return true
 }
 
+// StackRequired returns whether the test runner needs a running Elastic stack.
+func (r *runner) StackRequired() bool {
+	return true
+}
+
 // Type returns the type of test that can be run by this test runner.
 func (r *runner) Type() testrunner.TestType {
 	return TestType
@@ -0,0 +1,327 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/kylelemons/godebug/diff"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/multierror"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+const (
+	// TestType defining policy tests
+	TestType testrunner.TestType = "policy"
+
+	defaultStreamTemplatePath = "stream.yml.hbs"
+	expectedTestResultSuffix  = "-expected.yml"
+)
+
+type runner struct {
+	options testrunner.TestOptions
+}
+
+var _ testrunner.TestRunner = new(runner)
+
+func init() {
+	testrunner.RegisterRunner(&runner{})
+}
+
+// Type returns the type of test that can be run by this test runner.
+func (r *runner) Type() testrunner.TestType {
+	return TestType
+}
+
+// String returns the human-friendly name of the test runner.
+func (r *runner) String() string {
+	return "policy"
+}
+
+// CanRunPerDataStream returns whether this test runner can run on individual
+// data streams within the package.
+func (r *runner) CanRunPerDataStream() bool {
+	return true
+}
+
+func (r *runner) TestFolderRequired() bool {
+	return true
+}
+
+// StackRequired returns whether the test runner needs a running Elastic stack.
+func (r *runner) StackRequired() bool {
+	return false
+}
+
+// TearDown shuts down the policy test runner.
+func (r *runner) TearDown() error {
+	return nil // it's an offline test runner, no state is stored
+}
+
+// Run runs the policy tests defined under the given folder
+func (r *runner) Run(options testrunner.TestOptions) ([]testrunner.TestResult, error) {
+	r.options = options
+	return r.run()
+}
+
+func (r *runner) newResult(name string) *testrunner.ResultComposer {
+	return testrunner.NewResultComposer(testrunner.TestResult{
+		TestType:   TestType,
+		Name:       name,
+		Package:    r.options.TestFolder.Package,
+		DataStream: r.options.TestFolder.DataStream,
+	})
+}
+
+func (r *runner) run() ([]testrunner.TestResult, error) {
+	result := r.newResult("(init)")
+
+	files, err := listConfigFiles(r.options.TestFolder.Path)
+	if err != nil {
+		return result.WithError(errors.Wrap(err, "failed listing test case config files"))
+	}
+
+	var results []testrunner.TestResult
+	for _, cfgFile := range files {
+		testConfig, err := newConfig(filepath.Join(r.options.TestFolder.Path, cfgFile))
+		if err != nil {
+			return result.WithError(errors.Wrapf(err, "unable to load policy test case file '%s'", cfgFile))
+		}
+
+		var partial []testrunner.TestResult
+		if testConfig.Skip == nil {
+			partial, err = r.runTest(testConfig)
+		} else {
+			logger.Warnf("skipping %s test for %s/%s: %s (details: %s)",
+				TestType, r.options.TestFolder.Package, r.options.TestFolder.DataStream,
+				testConfig.Skip.Reason, testConfig.Skip.Link.String())
+			partial, err = r.newResult(testConfig.Name()).WithSkip(testConfig.Skip)
+		}
+
+		results = append(results, partial...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func (r *runner) runTest(config *testConfig) ([]testrunner.TestResult, error) {
+	result := r.newResult(config.Name())
+
+	pkgManifest, err := packages.ReadPackageManifestFromPackageRoot(r.options.PackageRootPath)
+	if err != nil {
+		return result.WithError(errors.Wrap(err, "reading package manifest failed"))
+	}
+
+	dataStreamPath, found, err := packages.FindDataStreamRootForPath(r.options.TestFolder.Path)
+	if err != nil {
+		return result.WithError(errors.Wrap(err, "locating data stream root failed"))
+	}
+	if !found {
+		return result.WithError(errors.New("data stream root not found"))
+	}
+
+	dataStreamManifest, err := packages.ReadDataStreamManifest(filepath.Join(dataStreamPath, packages.DataStreamManifestFile))
+	if err != nil {
+		return result.WithError(errors.Wrap(err, "reading data stream manifest failed"))
+	}
+
+	policyTemplate, err := pkgManifest.FindPolicyTemplate(config.PolicyTemplate)
+	if err != nil {
+		return result.WithError(errors.Wrap(err, "can't select policy template"))
+	}
+
+	streamIdx, err := findStreamIndex(config.Input, *dataStreamManifest)
+	if err != nil {
+		return result.WithError(err)
+	}
+	stream := dataStreamManifest.Streams[streamIdx]
+
+	templatePath := stream.TemplatePath
+	if templatePath == "" {
+		templatePath = defaultStreamTemplatePath
+	}
+	templatePath = filepath.Join(dataStreamPath, "agent", "stream", templatePath)
+	source, err := ioutil.ReadFile(templatePath)
+	if err != nil {
+		return result.WithError(errors.Wrapf(err, "reading stream template failed (path: %s)", templatePath))
+	}
+
+	// Collect declared variables with defaults, overlaid with values from the test configuration.
+	// Fleet renders the stream template with input-level (incl. package-level) and stream-level variables.
+	var problems multierror.Error
+	vars := map[string]interface{}{}
+	var packageVars []packages.Variable
+	if input := policyTemplate.FindInputByType(stream.Input); input != nil {
+		packageVars = append(packageVars, input.Vars...)
+	}
+	packageVars = append(packageVars, pkgManifest.Vars...)
+	problems = append(problems, overlayVariables(vars, packageVars, config.Vars, "vars")...)
+	problems = append(problems, overlayVariables(vars, stream.Vars, config.DataStream.Vars, "data_stream.vars")...)
+
+	used, err := templateVariables(string(source))
+	if err != nil {
+		return result.WithError(testrunner.ErrTestCaseFailed{
+			Reason:  "invalid stream template",
+			Details: err.Error(),
+		})
+	}
+	problems = append(problems, verifyTemplateVariables(used, vars, stream.Vars)...)
+
+	rendered, err := renderTemplate(string(source), vars)
+	if err != nil {
+		return result.WithError(testrunner.ErrTestCaseFailed{
+			Reason:  "rendering stream template failed",
+			Details: err.Error(),
+		})
+	}
+
+	var renderedConfig map[string]interface{}
+	if err := yaml.Unmarshal([]byte(rendered), &renderedConfig); err != nil {
+		problems = append(problems, errors.Wrap(err, "rendered stream template isn't valid YAML"))
+	}
+
+	if len(problems) > 0 {
+		return result.WithError(testrunner.ErrTestCaseFailed{
+			Reason:  "one or more problems found in stream template",
+			Details: problems.Unique().Error(),
+		})
+	}
+
+	if err := r.verifyRenderedConfig(config, renderedConfig); err != nil {
+		return result.WithError(err)
+	}
+	return result.WithSuccess()
+}
+
+func (r *runner) verifyRenderedConfig(config *testConfig, renderedConfig map[string]interface{}) error {
+	actual, err := yaml.Marshal(renderedConfig)
+	if err != nil {
+		return errors.Wrap(err, "marshalling rendered stream configuration failed")
+	}
+
+	expectedPath := expectedTestResultPath(config.Path)
+	if r.options.GenerateTestResult {
+		if err := ioutil.WriteFile(expectedPath, actual, 0644); err != nil {
+			return errors.Wrap(err, "writing test result failed")
+		}
+	}
+
+	data, err := ioutil.ReadFile(expectedPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading test result file failed (path: %s)", expectedPath)
+	}
+
+	// Normalize the expected configuration, so formatting differences aren't reported.
+	var expectedConfig map[string]interface{}
+	if err := yaml.Unmarshal(data, &expectedConfig); err != nil {
+		return errors.Wrapf(err, "unmarshalling expected test result failed (path: %s)", expectedPath)
+	}
+	expected, err := yaml.Marshal(expectedConfig)
+	if err != nil {
+		return errors.Wrap(err, "marshalling expected test result failed")
+	}
+
+	report := diff.Diff(string(expected), string(actual))
+	if report != "" {
+		return testrunner.ErrTestCaseFailed{
+			Reason:  "Expected results are different from actual ones",
+			Details: report,
+		}
+	}
+	return nil
+}
+
+// findStreamIndex returns the index of the stream whose input name matches. If the input
+// isn't defined in the test configuration, the first stream is selected.
+func findStreamIndex(inputName string, ds packages.DataStreamManifest) (int, error) {
+	if len(ds.Streams) == 0 {
+		return 0, errors.New("data stream doesn't define any streams")
+	}
+
+	if inputName == "" {
+		return 0, nil
+	}
+
+	for i, s := range ds.Streams {
+		if s.Input == inputName {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("stream not found (input: %s)", inputName)
+}
+
+// overlayVariables sets default values of declared variables and overlays them with values from
+// the test configuration. Variables in the test configuration, which aren't declared, are reported.
+func overlayVariables(vars map[string]interface{}, declared []packages.Variable, configured map[string]packages.VarValue, configKey string) multierror.Error {
+	for _, v := range declared {
+		vars[v.Name] = v.Default.Value()
+		if value, ok := configured[v.Name]; ok {
+			vars[v.Name] = value.Value()
+		}
+	}
+
+	var problems multierror.Error
+	for name := range configured {
+		if !isDeclared(name, declared) {
+			problems = append(problems, fmt.Errorf("variable \"%s\" in \"%s\" of the test configuration isn't declared", name, configKey))
+		}
+	}
+	return problems
+}
+
+// verifyTemplateVariables reports variables used in the template, which aren't declared, and stream variables,
+// which are declared but never used in the template.
+func verifyTemplateVariables(used []string, vars map[string]interface{}, streamVars []packages.Variable) multierror.Error {
+	var problems multierror.Error
+	for _, name := range used {
+		if _, ok := vars[name]; !ok {
+			problems = append(problems, fmt.Errorf("variable \"%s\" used in the template isn't declared", name))
+		}
+	}
+
+	for _, v := range streamVars {
+		if !isUsed(v.Name, used) {
+			problems = append(problems, fmt.Errorf("variable \"%s\" is declared, but not used in the template", v.Name))
+		}
+	}
+	return problems
+}
+
+func isDeclared(name string, declared []packages.Variable) bool {
+	for _, v := range declared {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isUsed(name string, used []string) bool {
+	for _, u := range used {
+		if u == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedTestResultPath returns the path of the golden file for the given policy test
+// configuration file, e.g. test-default-config.yml -> test-default-expected.yml.
+func expectedTestResultPath(configPath string) string {
+	name := filepath.Base(configPath)
+	if matches := policyTestConfigFilePattern.FindStringSubmatch(name); len(matches) > 1 {
+		name = fmt.Sprintf("test-%s", matches[1])
+	}
+	return filepath.Join(filepath.Dir(configPath), name+expectedTestResultSuffix)
+}
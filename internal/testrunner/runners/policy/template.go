@@ -0,0 +1,217 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aymerick/raymond"
+	"github.com/aymerick/raymond/ast"
+	"github.com/aymerick/raymond/parser"
+	"github.com/pkg/errors"
+)
+
+// builtinHelpers are helpers provided by the Handlebars engine.
+var builtinHelpers = []string{"if", "unless", "with", "each", "log", "lookup", "equal"}
+
+// fleetHelpers are helpers registered by Fleet while rendering stream templates.
+var fleetHelpers = map[string]interface{}{
+	"contains":      containsHelper,
+	"escape_string": escapeStringHelper,
+	"to_json":       toJSONHelper,
+}
+
+// renderTemplate renders the stream template with the given variables, the way Fleet does it (without HTML escaping).
+func renderTemplate(source string, vars map[string]interface{}) (string, error) {
+	tmpl, err := raymond.Parse(source)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing template failed")
+	}
+	tmpl.RegisterHelpers(fleetHelpers)
+
+	ctx := map[string]interface{}{}
+	for name, value := range vars {
+		ctx[name] = safeValue(value)
+	}
+
+	result, err := tmpl.Exec(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "rendering template failed")
+	}
+	return result, nil
+}
+
+// safeValue converts all strings in the value to raymond.SafeString, so they are not HTML-escaped.
+func safeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return raymond.SafeString(v)
+	case []interface{}:
+		var list []interface{}
+		for _, item := range v {
+			list = append(list, safeValue(item))
+		}
+		return list
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			m[key] = safeValue(item)
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+func containsHelper(elem interface{}, value interface{}, options *raymond.Options) interface{} {
+	needle := fmt.Sprint(elem)
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if fmt.Sprint(item) == needle {
+				return options.Fn()
+			}
+		}
+	case string, raymond.SafeString:
+		if strings.Contains(fmt.Sprint(v), needle) {
+			return options.Fn()
+		}
+	}
+	return ""
+}
+
+func escapeStringHelper(value interface{}) raymond.SafeString {
+	return raymond.SafeString("'" + strings.ReplaceAll(fmt.Sprint(value), "'", "''") + "'")
+}
+
+func toJSONHelper(value interface{}) raymond.SafeString {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return raymond.SafeString("")
+	}
+	return raymond.SafeString(body)
+}
+
+// templateVariables returns names of all variables referenced in the template, excluding
+// helpers, block parameters and paths relative to the context of "each" and "with" blocks.
+func templateVariables(source string) ([]string, error) {
+	program, err := parser.Parse(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template failed")
+	}
+
+	c := variablesCollector{
+		used: map[string]struct{}{},
+	}
+	c.walk(program)
+
+	var names []string
+	for name := range c.used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+type variablesCollector struct {
+	used map[string]struct{}
+
+	blockParams [][]string
+	// scopedDepth is the number of entered blocks that change the context (e.g. "each" without block parameters).
+	scopedDepth int
+}
+
+func (c *variablesCollector) walk(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Program:
+		if n == nil {
+			return
+		}
+		for _, statement := range n.Body {
+			c.walk(statement)
+		}
+	case *ast.MustacheStatement:
+		c.walkExpression(n.Expression)
+	case *ast.BlockStatement:
+		c.walkExpression(n.Expression)
+
+		helper := n.Expression.HelperName()
+		changesContext := helper == "each" || helper == "with"
+		if n.Program != nil && len(n.Program.BlockParams) > 0 {
+			c.blockParams = append(c.blockParams, n.Program.BlockParams)
+			c.walk(n.Program)
+			c.blockParams = c.blockParams[:len(c.blockParams)-1]
+		} else if changesContext {
+			c.scopedDepth++
+			c.walk(n.Program)
+			c.scopedDepth--
+		} else {
+			c.walk(n.Program)
+		}
+		c.walk(n.Inverse)
+	}
+}
+
+func (c *variablesCollector) walkExpression(e *ast.Expression) {
+	if e == nil {
+		return
+	}
+
+	if len(e.Params) == 0 && e.Hash == nil && !isHelper(e.HelperName()) {
+		c.walkParam(e.Path)
+		return
+	}
+
+	for _, param := range e.Params {
+		c.walkParam(param)
+	}
+	if e.Hash != nil {
+		for _, pair := range e.Hash.Pairs {
+			c.walkParam(pair.Val)
+		}
+	}
+}
+
+func (c *variablesCollector) walkParam(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Expression:
+		c.walkExpression(n)
+	case *ast.SubExpression:
+		c.walkExpression(n.Expression)
+	case *ast.PathExpression:
+		c.usePath(n)
+	}
+}
+
+func (c *variablesCollector) usePath(p *ast.PathExpression) {
+	if p.Data || p.Depth > 0 || len(p.Parts) == 0 || c.scopedDepth > 0 {
+		return
+	}
+
+	name := p.Parts[0]
+	for _, params := range c.blockParams {
+		for _, param := range params {
+			if param == name {
+				return
+			}
+		}
+	}
+	c.used[name] = struct{}{}
+}
+
+func isHelper(name string) bool {
+	if _, ok := fleetHelpers[name]; ok {
+		return true
+	}
+	for _, helper := range builtinHelpers {
+		if helper == name {
+			return true
+		}
+	}
+	return false
+}
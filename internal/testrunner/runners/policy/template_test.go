@@ -0,0 +1,51 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const streamTemplate = `paths:
+{{#each paths as |path i|}}
+  - {{path}}
+{{/each}}
+{{#if condition}}
+condition: {{condition}}
+{{/if}}
+{{#each processors}}
+  - {{name}}
+{{/each}}
+{{#contains "forwarded" tags}}
+forwarded: true
+{{/contains}}
+query: {{escape_string query}}
+{{@root.ignored}}
+`
+
+func TestTemplateVariables(t *testing.T) {
+	vars, err := templateVariables(streamTemplate)
+	require.NoError(t, err)
+	require.Equal(t, []string{"condition", "paths", "processors", "query", "tags"}, vars)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	rendered, err := renderTemplate(streamTemplate, map[string]interface{}{
+		"paths":     []interface{}{"/var/log/a.log", "/var/log/<b>.log"},
+		"condition": nil,
+		"tags":      []interface{}{"forwarded"},
+		"query":     "it's",
+	})
+	require.NoError(t, err)
+	require.Equal(t, `paths:
+  - /var/log/a.log
+  - /var/log/<b>.log
+forwarded: true
+query: 'it''s'
+
+`, rendered)
+}
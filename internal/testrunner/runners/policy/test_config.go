@@ -0,0 +1,76 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/elastic/go-ucfg"
+	"github.com/elastic/go-ucfg/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+var policyTestConfigFilePattern = regexp.MustCompile(`^test-([a-z0-9_.-]+)-config.yml$`)
+
+type testConfig struct {
+	testrunner.SkippableConfig `config:",inline"`
+
+	Input          string `config:"input"`
+	PolicyTemplate string `config:"policy_template"` // Name of the policy template to test, the first one by default.
+
+	Vars       map[string]packages.VarValue `config:"vars"`
+	DataStream struct {
+		Vars map[string]packages.VarValue `config:"vars"`
+	} `config:"data_stream"`
+
+	Path string
+}
+
+func (t testConfig) Name() string {
+	name := filepath.Base(t.Path)
+	if matches := policyTestConfigFilePattern.FindStringSubmatch(name); len(matches) > 1 {
+		name = matches[1]
+	}
+	return name
+}
+
+func newConfig(configFilePath string) (*testConfig, error) {
+	data, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load policy test configuration file: %s", configFilePath)
+	}
+
+	var c testConfig
+	cfg, err := yaml.NewConfig(data, ucfg.PathSep("."))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load policy test configuration file: %s", configFilePath)
+	}
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, errors.Wrapf(err, "unable to unpack policy test configuration file: %s", configFilePath)
+	}
+	c.Path = configFilePath
+	return &c, nil
+}
+
+func listConfigFiles(policyTestFolderPath string) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(policyTestFolderPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "can't read directory (path: %s)", policyTestFolderPath)
+	}
+
+	var files []string
+	for _, fi := range fileInfos {
+		if !fi.IsDir() && policyTestConfigFilePattern.MatchString(fi.Name()) {
+			files = append(files, fi.Name())
+		}
+	}
+	return files, nil
+}
@@ -128,3 +128,7 @@ func (r runner) CanRunPerDataStream() bool {
 func (r *runner) TestFolderRequired() bool {
 	return false
 }
+
+func (r runner) StackRequired() bool {
+	return false
+}
@@ -0,0 +1,180 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/builder"
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/stack"
+	"github.com/elastic/elastic-package/internal/testrunner"
+	"github.com/elastic/elastic-package/internal/testrunner/runners/system/servicedeployer"
+)
+
+const elasticAgentServiceName = "elastic-agent"
+
+// diagnostics keeps references to resources created while running a test case, so they can
+// be inspected after the test case completes, but before they are torn down.
+type diagnostics struct {
+	service           servicedeployer.DeployedService
+	kibanaClient      *kibana.Client
+	policyID          string
+	testedDataStreams []testedDataStream
+}
+
+func (r *runner) resetDiagnostics() {
+	r.diagnostics = diagnostics{}
+}
+
+// collectDiagnostics stores service logs, Elastic Agent logs, the rendered agent policy and
+// documents found in tested data streams in the test case's artifacts directory. Failures of
+// particular sections are logged, but they don't interrupt collecting remaining ones.
+func (r *runner) collectDiagnostics(config *testConfig, results []testrunner.TestResult) {
+	if !r.options.AlwaysCollectDiagnostics && !anyTestCaseFailed(results) {
+		return
+	}
+
+	dir, err := r.diagnosticsDir(config)
+	if err != nil {
+		logger.Errorf("can't prepare diagnostics directory: %v", err)
+		return
+	}
+
+	logger.Debugf("collecting diagnostics of %s test case...", config.Name())
+	d := r.diagnostics
+	if d.service != nil {
+		writeDiagnosticsFile(dir, "service.log", d.service.Logs)
+	}
+
+	for _, service := range r.elasticAgentServices() {
+		containerName := stack.ServiceContainerName(service)
+		writeDiagnosticsFile(dir, fmt.Sprintf("%s.log", service), func() ([]byte, error) {
+			return docker.ContainerLogs(containerName)
+		})
+	}
+
+	if d.kibanaClient != nil && d.policyID != "" {
+		writeDiagnosticsFile(dir, "agent-policy.json", func() ([]byte, error) {
+			policy, err := d.kibanaClient.GetFullPolicy(d.policyID)
+			if err != nil {
+				return nil, err
+			}
+			return indentJSON(policy)
+		})
+	}
+
+	for _, tds := range d.testedDataStreams {
+		index := tds.index
+		writeDiagnosticsFile(dir, fmt.Sprintf("hits-%s.json", index), func() ([]byte, error) {
+			docs, err := r.getDocs(index, elasticsearchQuerySize)
+			if err != nil {
+				return nil, err
+			}
+			return json.MarshalIndent(docs, "", "    ")
+		})
+	}
+
+	for _, result := range results {
+		if result.FailureDetails == "" {
+			continue
+		}
+
+		name := fmt.Sprintf("failure-details-%s.txt", result.DataStream)
+		writeDiagnosticsFile(dir, name, func() ([]byte, error) {
+			return []byte(result.FailureDetails), nil
+		})
+	}
+
+	for i := range results {
+		results[i].DiagnosticsPath = dir
+	}
+	logger.Infof("Diagnostics of %s test case stored in %s", config.Name(), dir)
+}
+
+// diagnosticsDir creates an empty artifacts directory for the test case,
+// e.g. build/test-artifacts/system/<package>/<data stream>/<test case>. If tests are run
+// against multiple stack versions, the stack version is appended to the test case directory.
+func (r *runner) diagnosticsDir(config *testConfig) (string, error) {
+	buildDir, found, err := builder.FindBuildDirectory()
+	if err != nil {
+		return "", errors.Wrap(err, "locating build directory failed")
+	}
+	if !found {
+		buildDir = filepath.Join(r.options.PackageRootPath, "build")
+	}
+
+	testCase := config.Name()
+	if r.options.StackVersion != "" {
+		testCase += "-" + r.options.StackVersion
+	}
+
+	dir := filepath.Join(buildDir, "test-artifacts", string(TestType),
+		r.options.TestFolder.Package, r.options.TestFolder.DataStream, testCase)
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't remove old diagnostics (path: %s)", dir)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't create diagnostics directory (path: %s)", dir)
+	}
+	return dir, nil
+}
+
+// elasticAgentServices returns names of Elastic Agent services started by the profile, including
+// additional replicas. If the profile can't be read, only the default Elastic Agent is considered.
+func (r *runner) elasticAgentServices() []string {
+	if r.options.Profile == nil {
+		return []string{elasticAgentServiceName}
+	}
+
+	services, err := stack.ElasticAgentServices(r.options.Profile)
+	if err != nil {
+		logger.Warnf("can't list Elastic Agent services, collecting logs of %s only: %v", elasticAgentServiceName, err)
+		return []string{elasticAgentServiceName}
+	}
+	return services
+}
+
+func writeDiagnosticsFile(dir, name string, fn func() ([]byte, error)) {
+	body, err := fn()
+	if err != nil {
+		logger.Warnf("can't collect diagnostics (file: %s): %v", name, err)
+		return
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, name), body, 0644)
+	if err != nil {
+		logger.Warnf("can't write diagnostics (file: %s): %v", name, err)
+	}
+}
+
+func anyTestCaseFailed(results []testrunner.TestResult) bool {
+	for _, result := range results {
+		if result.ErrorMsg != "" || result.FailureMsg != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func indentJSON(body []byte) ([]byte, error) {
+	var b bytes.Buffer
+	err := json.Indent(&b, body, "", "    ")
+	if err != nil {
+		return nil, errors.Wrap(err, "indenting JSON failed")
+	}
+	return b.Bytes(), nil
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
@@ -46,6 +47,10 @@ const (
 	// ServiceLogsAgentDir is folder path where log files produced by the service
 	// are stored on the Agent container's filesystem.
 	ServiceLogsAgentDir = "/tmp/service_logs"
+
+	// Number of consecutive checks with unchanged number of hits, after which
+	// the data stream is considered settled.
+	docsSettledChecks = 5
 )
 
 type runner struct {
@@ -56,6 +61,9 @@ type runner struct {
 	resetAgentPolicyHandler func() error
 	shutdownServiceHandler  func() error
 	wipeDataStreamHandler   func() error
+
+	// Resources of the currently running test case, used to collect diagnostics.
+	diagnostics diagnostics
 }
 
 // Type returns the type of test that can be run by this test runner.
@@ -78,6 +86,11 @@ func (r *runner) TestFolderRequired() bool {
 	return true
 }
 
+// StackRequired returns whether the test runner needs a running Elastic stack.
+func (r *runner) StackRequired() bool {
+	return true
+}
+
 // Run runs the system tests defined under the given folder
 func (r *runner) Run(options testrunner.TestOptions) ([]testrunner.TestResult, error) {
 	r.options = options
@@ -149,7 +162,9 @@ func (r *runner) run() (results []testrunner.TestResult, err error) {
 
 		var partial []testrunner.TestResult
 		if testConfig.Skip == nil {
+			r.resetDiagnostics()
 			partial, err = r.runTest(testConfig, ctxt)
+			r.collectDiagnostics(testConfig, partial)
 		} else {
 			logger.Warnf("skipping %s test for %s/%s: %s (details: %s)",
 				TestType, r.options.TestFolder.Package, r.options.TestFolder.DataStream,
@@ -173,11 +188,17 @@ func createTestRunID() string {
 	return fmt.Sprintf("%d", rand.Intn(testRunMaxID-testRunMinID)+testRunMinID)
 }
 
-func (r *runner) getDocs(dataStream string) ([]common.MapStr, error) {
+// getDocs returns documents of the data stream, sorted by timestamp. At least elasticsearchQuerySize documents
+// are returned, if there are so many.
+func (r *runner) getDocs(dataStream string, size int) ([]common.MapStr, error) {
+	if size < elasticsearchQuerySize {
+		size = elasticsearchQuerySize
+	}
+
 	resp, err := r.options.ESClient.Search(
 		r.options.ESClient.Search.WithIndex(dataStream),
 		r.options.ESClient.Search.WithSort("@timestamp:asc"),
-		r.options.ESClient.Search.WithSize(elasticsearchQuerySize),
+		r.options.ESClient.Search.WithSize(size),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not search data stream")
@@ -186,9 +207,6 @@ func (r *runner) getDocs(dataStream string) ([]common.MapStr, error) {
 
 	var results struct {
 		Hits struct {
-			Total struct {
-				Value int
-			}
 			Hits []struct {
 				Source common.MapStr `json:"_source"`
 			}
@@ -199,17 +217,43 @@ func (r *runner) getDocs(dataStream string) ([]common.MapStr, error) {
 		return nil, errors.Wrap(err, "could not decode search results response")
 	}
 
-	numHits := results.Hits.Total.Value
-	logger.Debugf("found %d hits in %s data stream", numHits, dataStream)
-
 	var docs []common.MapStr
 	for _, hit := range results.Hits.Hits {
 		docs = append(docs, hit.Source)
 	}
-
 	return docs, nil
 }
 
+// countDocs returns the number of documents in the data stream. Unlike the number of retrieved documents,
+// it isn't limited by the size of search results.
+func (r *runner) countDocs(dataStream string) (int, error) {
+	resp, err := r.options.ESClient.Count(
+		r.options.ESClient.Count.WithIndex(dataStream),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not count documents in data stream")
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		// The data stream may not exist yet.
+		if resp.StatusCode == http.StatusNotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("could not count documents in data stream: %s", resp.String())
+	}
+
+	var results struct {
+		Count int
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return 0, errors.Wrap(err, "could not decode count response")
+	}
+
+	logger.Debugf("found %d hits in %s data stream", results.Count, dataStream)
+	return results.Count, nil
+}
+
 func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext) ([]testrunner.TestResult, error) {
 	result := r.newResult(config.Name())
 
@@ -236,6 +280,12 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 	serviceDeployer, err := servicedeployer.Factory(servicedeployer.FactoryOptions{
 		PackageRootPath:    r.options.PackageRootPath,
 		DataStreamRootPath: dataStreamPath,
+		ServicePodSelector: config.ServicePodSelector,
+		DeployerName:       config.Deployer,
+
+		ServiceReadyTimeout: config.ServiceReadyTimeout,
+		KeepTerraformState:  r.options.KeepTerraformState,
+		Profile:             r.options.Profile,
 	})
 	if err != nil {
 		return result.WithError(errors.Wrap(err, "could not create service runner"))
@@ -249,6 +299,7 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 		return result.WithError(errors.Wrap(err, "could not setup service"))
 	}
 	ctxt = service.Context()
+	r.diagnostics.service = service
 	r.shutdownServiceHandler = func() error {
 		logger.Debug("tearing down service...")
 		if err := service.TearDown(); err != nil {
@@ -268,18 +319,20 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 	if err != nil {
 		return result.WithError(errors.Wrap(err, "can't create Kibana client"))
 	}
+	r.diagnostics.kibanaClient = kib
 
-	agents, err := checkEnrolledAgents(kib, ctxt)
+	// Agents provided by the service deployer are selected, unless the test configuration selects other ones.
+	selector := config.Agents
+	if selector.HostNamePrefix == "" {
+		selector.HostNamePrefix = ctxt.Agent.Host.NamePrefix
+	}
+	agents, err := checkEnrolledAgents(kib, selector, config.Agents.count())
 	if err != nil {
 		return result.WithError(errors.Wrap(err, "can't check enrolled agents"))
 	}
-	agent := agents[0]
-	origPolicy := kibana.Policy{
-		ID:       agent.PolicyID,
-		Revision: agent.PolicyRevision,
-	}
+	agents = agents[:config.Agents.count()]
 
-	// Configure package (single data stream) via Ingest Manager APIs.
+	// Configure package (one or more data streams) via Ingest Manager APIs.
 	logger.Debug("creating test policy...")
 	testTime := time.Now().Format("20060102T15:04:05Z")
 	p := kibana.Policy{
@@ -291,6 +344,7 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 	if err != nil {
 		return result.WithError(errors.Wrap(err, "could not create test policy"))
 	}
+	r.diagnostics.policyID = policy.ID
 	r.deleteTestPolicyHandler = func() error {
 		logger.Debug("deleting test policy...")
 		if err := kib.DeletePolicy(*policy); err != nil {
@@ -299,49 +353,77 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 		return nil
 	}
 
-	logger.Debug("adding package data stream to test policy...")
-	ds := createPackageDatastream(*policy, *pkgManifest, *dataStreamManifest, *config)
-	if err := kib.AddPackageDataStreamToPolicy(ds); err != nil {
-		return result.WithError(errors.Wrap(err, "could not add data stream config to policy"))
+	policyTemplate, err := pkgManifest.FindPolicyTemplate(config.PolicyTemplate)
+	if err != nil {
+		return result.WithError(errors.Wrap(err, "can't select policy template"))
 	}
 
-	// Delete old data
-	logger.Debug("deleting old data in data stream...")
-	dataStream := fmt.Sprintf(
-		"%s-%s-%s",
-		ds.Inputs[0].Streams[0].DataStream.Type,
-		ds.Inputs[0].Streams[0].DataStream.Dataset,
-		ds.Namespace,
-	)
+	testedDataStreams := []testedDataStream{
+		newTestedDataStream(r.options.TestFolder.DataStream, dataStreamPath,
+			createPackageDatastream(*policy, *pkgManifest, *policyTemplate, *dataStreamManifest, *config)),
+	}
+	for _, additional := range config.DataStreams {
+		additionalPath := filepath.Join(r.options.PackageRootPath, "data_stream", additional.Name)
+		additionalManifest, err := packages.ReadDataStreamManifest(filepath.Join(additionalPath, packages.DataStreamManifestFile))
+		if err != nil {
+			return result.WithError(errors.Wrapf(err, "reading manifest of additional data stream failed (name: %s)", additional.Name))
+		}
 
+		testedDataStreams = append(testedDataStreams, newTestedDataStream(additional.Name, additionalPath,
+			createPackageDatastream(*policy, *pkgManifest, *policyTemplate, *additionalManifest, additional.testConfig(*config))))
+	}
+
+	r.diagnostics.testedDataStreams = testedDataStreams
+
+	logger.Debug("adding package data streams to test policy...")
+	for _, tds := range testedDataStreams {
+		if err := kib.AddPackageDataStreamToPolicy(tds.packageDataStream); err != nil {
+			return result.WithError(errors.Wrapf(err, "could not add data stream config to policy (name: %s)", tds.name))
+		}
+	}
+
+	// Delete old data
+	logger.Debug("deleting old data in data streams...")
 	r.wipeDataStreamHandler = func() error {
-		logger.Debugf("deleting data in data stream...")
-		if err := deleteDataStreamDocs(r.options.ESClient, dataStream); err != nil {
-			return errors.Wrap(err, "error deleting data in data stream")
+		logger.Debugf("deleting data in data streams...")
+		for _, tds := range testedDataStreams {
+			if err := deleteDataStreamDocs(r.options.ESClient, tds.index); err != nil {
+				return errors.Wrapf(err, "error deleting data in data stream: %s", tds.index)
+			}
 		}
 		return nil
 	}
 
-	if err := deleteDataStreamDocs(r.options.ESClient, dataStream); err != nil {
-		return result.WithError(errors.Wrapf(err, "error deleting old data in data stream: %s", dataStream))
-	}
+	for _, tds := range testedDataStreams {
+		dataStream := tds.index
+		if err := deleteDataStreamDocs(r.options.ESClient, dataStream); err != nil {
+			return result.WithError(errors.Wrapf(err, "error deleting old data in data stream: %s", dataStream))
+		}
 
-	cleared, err := waitUntilTrue(func() (bool, error) {
-		docs, err := r.getDocs(dataStream)
-		return len(docs) == 0, err
-	}, 2*time.Minute)
-	if err != nil || !cleared {
-		if err == nil {
-			err = errors.New("unable to clear previous data")
+		cleared, err := waitUntilTrue(func() (bool, error) {
+			count, err := r.countDocs(dataStream)
+			return count == 0, err
+		}, 2*time.Minute)
+		if err != nil || !cleared {
+			if err == nil {
+				err = fmt.Errorf("unable to clear previous data in data stream: %s", dataStream)
+			}
+			return result.WithError(err)
 		}
-		return result.WithError(err)
 	}
 
-	// Assign policy to agent
+	// Assign policy to agents
+	var assignedAgents []kibana.Agent
 	r.resetAgentPolicyHandler = func() error {
-		logger.Debug("reassigning original policy back to agent...")
-		if err := kib.AssignPolicyToAgent(agent, origPolicy); err != nil {
-			return errors.Wrap(err, "error reassigning original policy to agent")
+		logger.Debug("reassigning original policy back to agents...")
+		for _, agent := range assignedAgents {
+			origPolicy := kibana.Policy{
+				ID:       agent.PolicyID,
+				Revision: agent.PolicyRevision,
+			}
+			if err := kib.AssignPolicyToAgent(agent, origPolicy); err != nil {
+				return errors.Wrapf(err, "error reassigning original policy to agent (ID: %s)", agent.ID)
+			}
 		}
 		return nil
 	}
@@ -351,9 +433,20 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 		return result.WithError(errors.Wrap(err, "could not read the policy with data stream"))
 	}
 
-	logger.Debug("assigning package data stream to agent...")
-	if err := kib.AssignPolicyToAgent(agent, *policyWithDataStream); err != nil {
-		return result.WithError(errors.Wrap(err, "could not assign policy to agent"))
+	// Verify the stream configuration rendered by Fleet before any data is shipped.
+	if config.VerifyStreamConfig {
+		logger.Debug("verifying rendered stream configuration...")
+		if err := r.verifyStreamConfig(kib, *policy, *config, testedDataStreams[0]); err != nil {
+			return result.WithError(err)
+		}
+	}
+
+	logger.Debug("assigning package data streams to agents...")
+	for _, agent := range agents {
+		assignedAgents = append(assignedAgents, agent)
+		if err := kib.AssignPolicyToAgent(agent, *policyWithDataStream); err != nil {
+			return result.WithError(errors.Wrapf(err, "could not assign policy to agent (ID: %s)", agent.ID))
+		}
 	}
 
 	// Signal to the service that the agent is ready (policy is assigned).
@@ -363,13 +456,47 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 		}
 	}
 
+	// Validate documents of every data stream separately, starting with the tested one.
+	results, err := r.validateTestedDataStream(result, config, testedDataStreams[0], true)
+	if err != nil {
+		return results, err
+	}
+	for _, tds := range testedDataStreams[1:] {
+		additionalResult := r.newResult(config.Name())
+		additionalResult.DataStream = tds.name
+
+		partial, err := r.validateTestedDataStream(additionalResult, config, tds, false)
+		results = append(results, partial...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// validateTestedDataStream waits for documents in the data stream and validates them. Expected documents
+// are verified and sample events are written only for the primary data stream, the one the test belongs to.
+func (r *runner) validateTestedDataStream(result *testrunner.ResultComposer, config *testConfig, tds testedDataStream, primary bool) ([]testrunner.TestResult, error) {
+	dataStream := tds.index
+	expectedDocumentsEnabled := primary && config.ExpectedDocuments.Enabled
+
+	// With expected documents enabled, wait at least for the number of documents stored in the golden file.
+	minDocs := 1
+	if expectedDocumentsEnabled && !r.options.GenerateTestResult {
+		expectedCount, err := countExpectedDocuments(config.Path)
+		if err != nil {
+			return result.WithError(errors.Wrap(err, "can't read expected documents"))
+		}
+		if expectedCount > minDocs {
+			minDocs = expectedCount
+		}
+	}
+
 	// (TODO in future) Optionally exercise service to generate load.
-	logger.Debug("checking for expected data in data stream...")
-	var docs []common.MapStr
+	logger.Debugf("checking for expected data in data stream %s...", dataStream)
 	passed, err := waitUntilTrue(func() (bool, error) {
-		var err error
-		docs, err = r.getDocs(dataStream)
-		return len(docs) > 0, err
+		count, err := r.countDocs(dataStream)
+		return count >= minDocs, err
 	}, 10*time.Minute)
 
 	if err != nil {
@@ -380,30 +507,109 @@ func (r *runner) runTest(config *testConfig, ctxt servicedeployer.ServiceContext
 		result.FailureMsg = fmt.Sprintf("could not find hits in %s data stream", dataStream)
 	}
 
+	size := elasticsearchQuerySize
+	if passed && expectedDocumentsEnabled {
+		// All documents are needed to compare them with expected ones.
+		size, err = r.waitUntilDocsSettled(dataStream)
+		if err != nil {
+			return result.WithError(err)
+		}
+	}
+
+	docs, err := r.getDocs(dataStream, size)
+	if err != nil {
+		return result.WithError(err)
+	}
+
 	// Validate fields in docs
-	fieldsValidator, err := fields.CreateValidatorForDataStream(dataStreamPath,
-		fields.WithNumericKeywordFields(config.NumericKeywordFields))
+	numericKeywordFields := config.NumericKeywordFields
+	if !primary {
+		numericKeywordFields = config.additionalDataStream(tds.name).NumericKeywordFields
+	}
+	fieldsValidator, err := fields.CreateValidatorForDataStream(tds.path,
+		fields.WithNumericKeywordFields(numericKeywordFields))
 	if err != nil {
-		return result.WithError(errors.Wrapf(err, "creating fields validator for data stream failed (path: %s)", dataStreamPath))
+		return result.WithError(errors.Wrapf(err, "creating fields validator for data stream failed (path: %s)", tds.path))
 	}
 
 	if err := validateFields(docs, fieldsValidator, dataStream); err != nil {
 		return result.WithError(err)
 	}
 
+	if !primary {
+		return result.WithSuccess()
+	}
+
 	// Write sample events file from first doc, if requested
 	if r.options.GenerateTestResult {
-		ds := r.options.TestFolder.DataStream
-		dsPath := filepath.Join(r.options.PackageRootPath, "data_stream", ds)
-		if err := writeSampleEvent(dsPath, docs[0]); err != nil {
+		if err := writeSampleEvent(tds.path, docs[0]); err != nil {
 			return result.WithError(errors.Wrap(err, "failed to write sample event file"))
 		}
 	}
 
+	// Compare normalized documents with expected ones, if enabled
+	if expectedDocumentsEnabled {
+		if err := verifyExpectedDocuments(docs, *config, r.options.GenerateTestResult); err != nil {
+			return result.WithError(err)
+		}
+	}
+
 	return result.WithSuccess()
 }
 
-func checkEnrolledAgents(client *kibana.Client, ctxt servicedeployer.ServiceContext) ([]kibana.Agent, error) {
+// waitUntilDocsSettled polls the data stream until the number of hits doesn't change anymore,
+// so that the set of documents is complete and can be compared with expected documents. It returns
+// the final number of documents.
+func (r *runner) waitUntilDocsSettled(dataStream string) (int, error) {
+	logger.Debug("waiting for the number of hits to settle...")
+
+	lastCount, stableChecks := -1, 0
+	settled, err := waitUntilTrue(func() (bool, error) {
+		count, err := r.countDocs(dataStream)
+		if err != nil {
+			return false, err
+		}
+
+		if count == lastCount {
+			stableChecks++
+		} else {
+			lastCount = count
+			stableChecks = 0
+		}
+		return stableChecks >= docsSettledChecks, nil
+	}, 2*time.Minute)
+	if err != nil {
+		return 0, err
+	}
+	if !settled {
+		logger.Warnf("number of hits in %s data stream didn't settle in time", dataStream)
+	}
+	return lastCount, nil
+}
+
+func verifyExpectedDocuments(docs []common.MapStr, config testConfig, generate bool) error {
+	normalized, err := normalizeDocuments(docs, config.ExpectedDocuments)
+	if err != nil {
+		return errors.Wrap(err, "normalizing documents failed")
+	}
+
+	if generate {
+		if err := writeTestResult(config.Path, normalized); err != nil {
+			return errors.Wrap(err, "writing expected documents failed")
+		}
+	}
+
+	err = compareResults(config.Path, normalized)
+	if _, ok := err.(testrunner.ErrTestCaseFailed); ok {
+		return err
+	}
+	if err != nil {
+		return errors.Wrap(err, "comparing expected documents failed")
+	}
+	return nil
+}
+
+func checkEnrolledAgents(client *kibana.Client, selector agentsConfig, minAgents int) ([]kibana.Agent, error) {
 	var agents []kibana.Agent
 	enrolled, err := waitUntilTrue(func() (bool, error) {
 		allAgents, err := client.ListAgents()
@@ -411,9 +617,9 @@ func checkEnrolledAgents(client *kibana.Client, ctxt servicedeployer.ServiceCont
 			return false, errors.Wrap(err, "could not list agents")
 		}
 
-		agents = filterAgents(allAgents, ctxt)
+		agents = filterAgents(allAgents, selector)
 		logger.Debugf("found %d enrolled agent(s)", len(agents))
-		if len(agents) == 0 {
+		if len(agents) < minAgents {
 			return false, nil // selected agents are unavailable yet
 		}
 		return true, nil
@@ -422,14 +628,37 @@ func checkEnrolledAgents(client *kibana.Client, ctxt servicedeployer.ServiceCont
 		return nil, errors.Wrap(err, "agent enrollment failed")
 	}
 	if !enrolled {
-		return nil, errors.New("no agent enrolled in time")
+		return nil, fmt.Errorf("expected %d agent(s) enrolled in time, found %d", minAgents, len(agents))
 	}
 	return agents, nil
 }
 
+// testedDataStream represents a data stream enabled in the test policy, whose documents are validated.
+type testedDataStream struct {
+	name              string
+	path              string
+	index             string
+	packageDataStream kibana.PackageDataStream
+}
+
+func newTestedDataStream(name, path string, ds kibana.PackageDataStream) testedDataStream {
+	return testedDataStream{
+		name: name,
+		path: path,
+		index: fmt.Sprintf(
+			"%s-%s-%s",
+			ds.Inputs[0].Streams[0].DataStream.Type,
+			ds.Inputs[0].Streams[0].DataStream.Dataset,
+			ds.Namespace,
+		),
+		packageDataStream: ds,
+	}
+}
+
 func createPackageDatastream(
 	p kibana.Policy,
 	pkg packages.PackageManifest,
+	pt packages.PolicyTemplate,
 	ds packages.DataStreamManifest,
 	c testConfig,
 ) kibana.PackageDataStream {
@@ -452,6 +681,9 @@ func createPackageDatastream(
 			Enabled: true,
 		},
 	}
+	if c.PolicyTemplate != "" {
+		r.Inputs[0].PolicyTemplate = pt.Name
+	}
 
 	streams := []kibana.Stream{
 		{
@@ -485,7 +717,7 @@ func createPackageDatastream(
 
 	// Add package-level vars
 	pkgVars := kibana.Vars{}
-	input := pkg.PolicyTemplates[0].FindInputByType(streamInput)
+	input := pt.FindInputByType(streamInput)
 	if input != nil {
 		// copy package-level vars into each input
 		input.Vars = append(input.Vars, pkg.Vars...)
@@ -548,10 +780,8 @@ func waitUntilTrue(fn func() (bool, error), timeout time.Duration) (bool, error)
 	return false, nil
 }
 
-func filterAgents(allAgents []kibana.Agent, ctx servicedeployer.ServiceContext) []kibana.Agent {
-	if ctx.Agent.Host.NamePrefix != "" {
-		logger.Debugf("filter agents using criteria: NamePrefix=%s", ctx.Agent.Host.NamePrefix)
-	}
+func filterAgents(allAgents []kibana.Agent, selector agentsConfig) []kibana.Agent {
+	logger.Debugf("filter agents using criteria: NamePrefix=%s, PolicyID=%s, Tags=%v", selector.HostNamePrefix, selector.PolicyID, selector.Tags)
 
 	var filtered []kibana.Agent
 	for _, agent := range allAgents {
@@ -559,7 +789,7 @@ func filterAgents(allAgents []kibana.Agent, ctx servicedeployer.ServiceContext)
 			continue // For some reason Kibana doesn't always return a valid policy revision (eventually it will be present and valid)
 		}
 
-		if ctx.Agent.Host.NamePrefix != "" && !strings.HasPrefix(agent.LocalMetadata.Host.Name, ctx.Agent.Host.NamePrefix) {
+		if !selector.matches(agent) {
 			continue
 		}
 		filtered = append(filtered, agent)
@@ -0,0 +1,186 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"testing"
+
+	"github.com/elastic/go-ucfg"
+	"github.com/elastic/go-ucfg/yaml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+const testDataStreamManifestYAML = `
+type: logs
+streams:
+  - input: logfile
+    vars:
+      - name: paths
+        type: text
+        default: /var/log/default.log
+  - input: httpjson
+    vars:
+      - name: url
+        type: text
+        default: http://localhost
+      - name: interval
+        type: text
+        default: 1m
+`
+
+const testConfigYAML = `
+policy_template: sample
+vars:
+  api_key: secret
+data_streams:
+  - name: events
+    input: httpjson
+    vars:
+      url: http://service:8080
+    numeric_keyword_fields:
+      - event.code
+`
+
+func TestAgentsConfigCount(t *testing.T) {
+	cases := []struct {
+		title    string
+		count    int
+		expected int
+	}{
+		{"undefined", 0, 1},
+		{"negative", -1, 1},
+		{"single", 1, 1},
+		{"multiple", 3, 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			require.Equal(t, c.expected, agentsConfig{Count: c.count}.count())
+		})
+	}
+}
+
+func TestFilterAgents(t *testing.T) {
+	agents := []kibana.Agent{
+		testAgent("a", "docker-fleet-agent-1", "policy-1", 1, "linux"),
+		testAgent("b", "docker-fleet-agent-2", "policy-2", 1, "linux", "kubernetes"),
+		testAgent("c", "elastic-agent-1", "policy-2", 1, "windows"),
+		testAgent("d", "docker-fleet-agent-3", "policy-1", 0, "linux"),
+	}
+
+	cases := []struct {
+		title    string
+		selector agentsConfig
+		expected []string
+	}{
+		{"no criteria", agentsConfig{}, []string{"a", "b", "c"}},
+		{"host name prefix", agentsConfig{HostNamePrefix: "docker-fleet-agent"}, []string{"a", "b"}},
+		{"policy", agentsConfig{PolicyID: "policy-2"}, []string{"b", "c"}},
+		{"single tag", agentsConfig{Tags: []string{"linux"}}, []string{"a", "b"}},
+		{"all tags", agentsConfig{Tags: []string{"linux", "kubernetes"}}, []string{"b"}},
+		{"all criteria", agentsConfig{HostNamePrefix: "docker-fleet-agent", PolicyID: "policy-1", Tags: []string{"linux"}}, []string{"a"}},
+		{"no match", agentsConfig{PolicyID: "policy-3"}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			var ids []string
+			for _, agent := range filterAgents(agents, c.selector) {
+				ids = append(ids, agent.ID)
+			}
+			require.Equal(t, c.expected, ids)
+		})
+	}
+}
+
+func TestAdditionalDataStreamConfig(t *testing.T) {
+	config := readTestConfig(t, testConfigYAML)
+
+	cases := []struct {
+		title         string
+		name          string
+		expectedInput string
+		expectedVars  []string
+		expectedKeys  []string
+	}{
+		{"defined", "events", "httpjson", []string{"url"}, []string{"event.code"}},
+		{"not defined", "audit", "", nil, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			additional := config.additionalDataStream(c.name)
+			require.Equal(t, c.name, additional.Name)
+
+			dsConfig := additional.testConfig(config)
+			require.Equal(t, c.expectedInput, dsConfig.Input)
+			require.Equal(t, config.PolicyTemplate, dsConfig.PolicyTemplate)
+			require.Equal(t, config.Vars, dsConfig.Vars)
+			require.Equal(t, c.expectedKeys, dsConfig.NumericKeywordFields)
+
+			var vars []string
+			for name := range dsConfig.DataStream.Vars {
+				vars = append(vars, name)
+			}
+			require.Equal(t, c.expectedVars, vars)
+		})
+	}
+}
+
+func TestAdditionalTestedDataStream(t *testing.T) {
+	config := readTestConfig(t, testConfigYAML)
+
+	var ds packages.DataStreamManifest
+	unpackYAML(t, testDataStreamManifestYAML, &ds)
+	ds.Name = "events"
+
+	pkg := packages.PackageManifest{Name: "sample", Title: "Sample", Version: "1.0.0"}
+	pt := packages.PolicyTemplate{Name: "sample"}
+	policy := kibana.Policy{ID: "policy-1"}
+
+	packageDataStream := createPackageDatastream(policy, pkg, pt, ds, config.additionalDataStream("events").testConfig(config))
+	tested := newTestedDataStream("events", "/packages/sample/data_stream/events", packageDataStream)
+
+	require.Equal(t, "logs-sample.events-ep", tested.index)
+	require.Equal(t, "policy-1", tested.packageDataStream.PolicyID)
+	require.Len(t, tested.packageDataStream.Inputs, 1)
+
+	input := tested.packageDataStream.Inputs[0]
+	require.Equal(t, "httpjson", input.Type)
+	require.Equal(t, "sample", input.PolicyTemplate)
+	require.Len(t, input.Streams, 1)
+	require.Equal(t, "httpjson-sample.events", input.Streams[0].ID)
+
+	vars := input.Streams[0].Vars
+	require.Len(t, vars, 2)
+	require.Equal(t, "http://service:8080", vars["url"].Value.Value())
+	require.Equal(t, "1m", vars["interval"].Value.Value())
+}
+
+func testAgent(id, hostName, policyID string, policyRevision int, tags ...string) kibana.Agent {
+	agent := kibana.Agent{
+		ID:             id,
+		PolicyID:       policyID,
+		PolicyRevision: policyRevision,
+		Tags:           tags,
+	}
+	agent.LocalMetadata.Host.Name = hostName
+	return agent
+}
+
+func readTestConfig(t *testing.T, body string) testConfig {
+	var c testConfig
+	unpackYAML(t, body, &c)
+	return c
+}
+
+func unpackYAML(t *testing.T, body string, to interface{}) {
+	cfg, err := yaml.NewConfig([]byte(body), ucfg.PathSep("."))
+	require.NoError(t, err)
+	require.NoError(t, cfg.Unpack(to))
+}
@@ -6,6 +6,7 @@ package servicedeployer
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -19,7 +20,8 @@ import (
 // DockerComposeServiceDeployer knows how to deploy a service defined via
 // a Docker Compose file.
 type DockerComposeServiceDeployer struct {
-	ymlPaths []string
+	ymlPaths     []string
+	readyTimeout time.Duration
 }
 
 type dockerComposeDeployedService struct {
@@ -27,12 +29,20 @@ type dockerComposeDeployedService struct {
 
 	ymlPaths []string
 	project  string
+
+	// env contains additional environment variables passed to Docker Compose commands.
+	env []string
 }
 
 // NewDockerComposeServiceDeployer returns a new instance of a DockerComposeServiceDeployer.
-func NewDockerComposeServiceDeployer(ymlPaths []string) (*DockerComposeServiceDeployer, error) {
+// The deployer waits for healthy service containers up to the ready timeout (DefaultServiceReadyTimeout, if not set).
+func NewDockerComposeServiceDeployer(ymlPaths []string, readyTimeout time.Duration) (*DockerComposeServiceDeployer, error) {
+	if readyTimeout == 0 {
+		readyTimeout = DefaultServiceReadyTimeout
+	}
 	return &DockerComposeServiceDeployer{
-		ymlPaths: ymlPaths,
+		ymlPaths:     ymlPaths,
+		readyTimeout: readyTimeout,
 	}, nil
 }
 
@@ -72,6 +82,18 @@ func (r *DockerComposeServiceDeployer) SetUp(inCtxt ServiceContext) (DeployedSer
 		return nil, errors.Wrap(err, "could not boot up service using docker compose")
 	}
 
+	err = waitForHealthyContainers(p, compose.CommandOptions{Env: opts.Env}, r.readyTimeout)
+	if err != nil {
+		downErr := p.Down(compose.CommandOptions{
+			Env:       opts.Env,
+			ExtraArgs: []string{"-v"},
+		})
+		if downErr != nil {
+			logger.Errorf("could not shut down service using docker compose: %v", downErr)
+		}
+		return nil, errors.Wrap(err, "service is not ready")
+	}
+
 	// Build service container name
 	serviceContainer := fmt.Sprintf("%s_%s_1", service.project, serviceName)
 	outCtxt.Hostname = serviceContainer
@@ -113,7 +135,10 @@ func (s *dockerComposeDeployedService) Signal(signal string) error {
 		return errors.Wrap(err, "could not create docker compose project for service")
 	}
 
-	opts := compose.CommandOptions{ExtraArgs: []string{"-s", signal}}
+	opts := compose.CommandOptions{
+		Env:       s.composeEnv(),
+		ExtraArgs: []string{"-s", signal},
+	}
 	if s.ctxt.Name != "" {
 		opts.Services = append(opts.Services, s.ctxt.Name)
 	}
@@ -121,6 +146,22 @@ func (s *dockerComposeDeployedService) Signal(signal string) error {
 	return errors.Wrapf(p.Kill(opts), "could not send %q signal", signal)
 }
 
+// Logs returns logs of all containers in the service's Docker Compose project.
+func (s *dockerComposeDeployedService) Logs() ([]byte, error) {
+	p, err := compose.NewProject(s.project, s.ymlPaths...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create docker compose project for service")
+	}
+
+	logs, err := p.Logs(compose.CommandOptions{
+		Env: s.composeEnv(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read service logs using docker compose")
+	}
+	return logs, nil
+}
+
 // TearDown tears down the service.
 func (s *dockerComposeDeployedService) TearDown() error {
 	logger.Debugf("tearing down service using docker compose runner")
@@ -137,13 +178,17 @@ func (s *dockerComposeDeployedService) TearDown() error {
 	}
 
 	if err := p.Down(compose.CommandOptions{
-		Env: []string{fmt.Sprintf("%s=%s", serviceLogsDirEnv, s.ctxt.Logs.Folder.Local)},
+		Env: s.composeEnv(),
 	}); err != nil {
 		return errors.Wrap(err, "could not shut down service using docker compose")
 	}
 	return nil
 }
 
+func (s *dockerComposeDeployedService) composeEnv() []string {
+	return append([]string{fmt.Sprintf("%s=%s", serviceLogsDirEnv, s.ctxt.Logs.Folder.Local)}, s.env...)
+}
+
 // Context returns the current context for the service.
 func (s *dockerComposeDeployedService) Context() ServiceContext {
 	return s.ctxt
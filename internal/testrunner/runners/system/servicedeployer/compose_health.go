@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/compose"
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const (
+	// DefaultServiceReadyTimeout is the default time to wait for service containers to become healthy.
+	DefaultServiceReadyTimeout = 5 * time.Minute
+
+	healthStatusHealthy = "healthy"
+
+	healthCheckInterval = time.Second
+)
+
+// waitForHealthyContainers waits until all containers of the Docker Compose project, which define a health check,
+// are healthy. It fails immediately if any container exits, as service containers are expected to keep running.
+func waitForHealthyContainers(p *compose.Project, opts compose.CommandOptions, timeout time.Duration) error {
+	logger.Debugf("wait for healthy service containers (timeout: %s)", timeout)
+
+	startTime := time.Now()
+	for {
+		containerIDs, err := p.ContainerIDs(opts)
+		if err != nil {
+			return errors.Wrap(err, "can't list service containers")
+		}
+		if len(containerIDs) == 0 {
+			return nil
+		}
+
+		descriptions, err := docker.InspectContainers(containerIDs...)
+		if err != nil {
+			return errors.Wrap(err, "can't inspect service containers")
+		}
+
+		notReady, err := containersHealthy(descriptions)
+		if err != nil {
+			return err
+		}
+		if notReady == nil {
+			logger.Debug("all service containers are healthy")
+			return nil
+		}
+
+		if time.Now().Sub(startTime) > timeout {
+			return errors.Wrapf(notReady, "service containers are not healthy in time (timeout: %s)", timeout)
+		}
+		time.Sleep(healthCheckInterval)
+	}
+}
+
+// containersHealthy returns a non-nil notReady error describing the first container which isn't healthy yet,
+// and a non-nil err if any container has failed.
+func containersHealthy(descriptions []docker.ContainerDescription) (notReady error, err error) {
+	for _, c := range descriptions {
+		name := strings.TrimPrefix(c.Name, "/")
+		if c.State.Status == "exited" || c.State.Status == "dead" {
+			return nil, fmt.Errorf("service container %s exited unexpectedly (status: %s, exit code: %d)", name, c.State.Status, c.State.ExitCode)
+		}
+
+		if c.State.Health == nil || c.State.Health.Status == healthStatusHealthy {
+			continue
+		}
+
+		lastLog := "no health check performed yet"
+		if n := len(c.State.Health.Log); n > 0 {
+			last := c.State.Health.Log[n-1]
+			lastLog = fmt.Sprintf("exit code: %d, output: %q", last.ExitCode, strings.TrimSpace(last.Output))
+		}
+		notReady = fmt.Errorf("service container %s is %s (last health check: %s)", name, c.State.Health.Status, lastLog)
+		return notReady, nil
+	}
+	return nil, nil
+}
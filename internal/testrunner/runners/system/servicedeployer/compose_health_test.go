@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/docker"
+)
+
+func TestContainersHealthy(t *testing.T) {
+	cases := []struct {
+		title    string
+		inspect  string
+		notReady string
+		failed   string
+	}{
+		{
+			title: "healthy and without health check",
+			inspect: `[
+				{"Name": "/service_1", "State": {"Status": "running", "Health": {"Status": "healthy"}}},
+				{"Name": "/other_1", "State": {"Status": "running"}}
+			]`,
+		},
+		{
+			title: "starting",
+			inspect: `[
+				{"Name": "/service_1", "State": {"Status": "running", "Health": {"Status": "starting", "Log": [
+					{"ExitCode": 1, "Output": "connection refused\n"}
+				]}}}
+			]`,
+			notReady: `service container service_1 is starting (last health check: exit code: 1, output: "connection refused")`,
+		},
+		{
+			title: "exited",
+			inspect: `[
+				{"Name": "/service_1", "State": {"Status": "exited", "ExitCode": 137}}
+			]`,
+			failed: "service container service_1 exited unexpectedly (status: exited, exit code: 137)",
+		},
+		{
+			title: "completed",
+			inspect: `[
+				{"Name": "/service_1", "State": {"Status": "running"}},
+				{"Name": "/init_1", "State": {"Status": "exited", "ExitCode": 0}}
+			]`,
+			failed: "service container init_1 exited unexpectedly (status: exited, exit code: 0)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			var descriptions []docker.ContainerDescription
+			require.NoError(t, json.Unmarshal([]byte(c.inspect), &descriptions))
+
+			notReady, err := containersHealthy(descriptions)
+			if c.failed != "" {
+				require.EqualError(t, err, c.failed)
+				return
+			}
+			require.NoError(t, err)
+
+			if c.notReady != "" {
+				require.EqualError(t, notReady, c.notReady)
+			} else {
+				require.NoError(t, notReady)
+			}
+		})
+	}
+}
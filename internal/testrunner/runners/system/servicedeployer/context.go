@@ -14,19 +14,19 @@ const (
 // templates in system test configuration files, for example: {{ Hostname }}.
 type ServiceContext struct {
 	// Name is the name of the service.
-	Name string
+	Name string `json:"name"`
 
 	// Hostname is the host name of the service, as addressable from
 	// the Agent container.
-	Hostname string
+	Hostname string `json:"hostname"`
 
 	// Ports is a list of ports that the service listens on, as addressable
 	// from the Agent container.
-	Ports []int
+	Ports []int `json:"ports"`
 
 	// Port points to the first port in the list of ports. It's provided as
 	// a convenient shortcut as most services tend to listen on a single port.
-	Port int
+	Port int `json:"port"`
 
 	// Logs contains folder paths for log files produced by the service.
 	Logs struct {
@@ -34,31 +34,31 @@ type ServiceContext struct {
 			// Local contains the folder path where log files produced by
 			// the service are stored on the local filesystem, i.e. where
 			// elastic-package is running.
-			Local string
+			Local string `json:"local"`
 
 			// Agent contains the folder path where log files produced by
 			// the service are stored on the Agent container's filesystem.
-			Agent string
-		}
-	}
+			Agent string `json:"agent"`
+		} `json:"folder"`
+	} `json:"logs"`
 
 	// Test related properties.
 	Test struct {
 		// RunID identifies the current test run.
-		RunID string
-	}
+		RunID string `json:"run_id"`
+	} `json:"test"`
 
 	// Agent related properties.
 	Agent struct {
 		// Host describes the machine which is running the agent.
 		Host struct {
 			// Name prefix for the host's name
-			NamePrefix string
-		}
-	}
+			NamePrefix string `json:"name_prefix"`
+		} `json:"host"`
+	} `json:"agent"`
 
 	// CustomProperties store additional data used to boot up the service, e.g. AWS credentials.
-	CustomProperties map[string]interface{}
+	CustomProperties map[string]interface{} `json:"custom_properties,omitempty"`
 }
 
 // Aliases method returned aliases to properties of the service context.
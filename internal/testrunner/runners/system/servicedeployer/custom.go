@@ -0,0 +1,163 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const (
+	customDeployerExecutable = "deployer"
+
+	customDeployerSetUpCommand    = "setup"
+	customDeployerTearDownCommand = "teardown"
+	customDeployerSignalCommand   = "signal"
+	customDeployerLogsCommand     = "logs"
+)
+
+// CustomServiceDeployer delegates deploying the service to an external executable (plugin).
+// The executable is invoked with one of subcommands:
+//
+//	setup             - boots up the service; prints the updated service context (JSON) to stdout,
+//	teardown          - tears down the service,
+//	signal <SIGNAL>   - sends the signal to the service,
+//	logs              - prints logs of the service to stdout.
+//
+// The current service context is passed as JSON to stdin of every subcommand.
+type CustomServiceDeployer struct {
+	definitionsDir string
+}
+
+type customDeployedService struct {
+	ctxt ServiceContext
+
+	definitionsDir string
+}
+
+// NewCustomServiceDeployer creates an instance of CustomServiceDeployer.
+func NewCustomServiceDeployer(definitionsDir string) (*CustomServiceDeployer, error) {
+	return &CustomServiceDeployer{
+		definitionsDir: definitionsDir,
+	}, nil
+}
+
+// SetUp invokes the "setup" subcommand of the deployer and returns the service with the context updated by the deployer.
+func (d CustomServiceDeployer) SetUp(inCtxt ServiceContext) (DeployedService, error) {
+	logger.Debug("setting up service using custom service deployer")
+
+	output, err := runCustomDeployer(d.definitionsDir, inCtxt, customDeployerSetUpCommand)
+	if err != nil {
+		return nil, errors.Wrap(err, "custom deployer failed to set up the service")
+	}
+
+	outCtxt := inCtxt
+	err = json.Unmarshal(output, &outCtxt)
+	if err != nil {
+		// The service has been set up, so it needs to be torn down, as the runner won't do it.
+		_, tdErr := runCustomDeployer(d.definitionsDir, inCtxt, customDeployerTearDownCommand)
+		if tdErr != nil {
+			logger.Errorf("custom deployer failed to tear down the service: %v", tdErr)
+		}
+		return nil, errors.Wrapf(err, "can't unmarshal service context returned by custom deployer (output: %q)", string(output))
+	}
+
+	if outCtxt.Port == 0 && len(outCtxt.Ports) > 0 {
+		outCtxt.Port = outCtxt.Ports[0]
+	}
+
+	// The service is monitored by the Elastic Agent of the stack, unless the deployer provides its own agents.
+	if outCtxt.Agent.Host.NamePrefix == "" {
+		outCtxt.Agent.Host.NamePrefix = "docker-fleet-agent"
+	}
+
+	return &customDeployedService{
+		ctxt:           outCtxt,
+		definitionsDir: d.definitionsDir,
+	}, nil
+}
+
+// TearDown invokes the "teardown" subcommand of the deployer.
+func (s *customDeployedService) TearDown() error {
+	logger.Debug("tearing down service using custom service deployer")
+
+	_, err := runCustomDeployer(s.definitionsDir, s.ctxt, customDeployerTearDownCommand)
+	if err != nil {
+		return errors.Wrap(err, "custom deployer failed to tear down the service")
+	}
+	return nil
+}
+
+// Signal invokes the "signal" subcommand of the deployer.
+func (s *customDeployedService) Signal(signal string) error {
+	_, err := runCustomDeployer(s.definitionsDir, s.ctxt, customDeployerSignalCommand, signal)
+	if err != nil {
+		return errors.Wrapf(err, "custom deployer failed to send %q signal", signal)
+	}
+	return nil
+}
+
+// Logs invokes the "logs" subcommand of the deployer.
+func (s *customDeployedService) Logs() ([]byte, error) {
+	output, err := runCustomDeployer(s.definitionsDir, s.ctxt, customDeployerLogsCommand)
+	if err != nil {
+		return nil, errors.Wrap(err, "custom deployer failed to read logs")
+	}
+	return output, nil
+}
+
+// Context returns the current context for the service.
+func (s *customDeployedService) Context() ServiceContext {
+	return s.ctxt
+}
+
+// SetContext sets the current context for the service.
+func (s *customDeployedService) SetContext(ctxt ServiceContext) error {
+	s.ctxt = ctxt
+	return nil
+}
+
+// runCustomDeployer runs the deployer in the definitions directory and returns its standard output.
+func runCustomDeployer(definitionsDir string, ctxt ServiceContext, args ...string) ([]byte, error) {
+	input, err := json.Marshal(ctxt)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't marshal service context")
+	}
+
+	executable, err := filepath.Abs(filepath.Join(definitionsDir, customDeployerExecutable))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't locate deployer executable")
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = definitionsDir
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", serviceLogsDirEnv, ctxt.Logs.Folder.Local),
+		fmt.Sprintf("%s=%s", testRunIDEnv, ctxt.Test.RunID))
+	cmd.Stdin = bytes.NewReader(input)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "deployer command failed (stderr=%q)", errOutput.String())
+	}
+	if errOutput.Len() > 0 {
+		logger.Debugf("deployer stderr: %s", errOutput.String())
+	}
+	return output, nil
+}
+
+var _ ServiceDeployer = new(CustomServiceDeployer)
+var _ DeployedService = new(customDeployedService)
@@ -0,0 +1,108 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const customDeployerScript = `#!/bin/sh
+case "$1" in
+setup)
+  echo '{"hostname": "service.local", "ports": [8080, 8081], "custom_properties": {"TOKEN": "secret"}}'
+  ;;
+signal)
+  echo "$2 $TEST_RUN_ID" > signal.txt
+  ;;
+logs)
+  echo "service logs"
+  ;;
+teardown)
+  cat > teardown.json
+  ;;
+*)
+  exit 1
+  ;;
+esac
+`
+
+func TestCustomServiceDeployer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported")
+	}
+
+	dir, err := ioutil.TempDir("", "custom")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, customDeployerExecutable), []byte(customDeployerScript), 0755)
+	require.NoError(t, err)
+
+	deployer, err := NewCustomServiceDeployer(dir)
+	require.NoError(t, err)
+
+	var ctxt ServiceContext
+	ctxt.Name = "service"
+	ctxt.Test.RunID = "12345"
+
+	service, err := deployer.SetUp(ctxt)
+	require.NoError(t, err)
+
+	outCtxt := service.Context()
+	require.Equal(t, "service", outCtxt.Name)
+	require.Equal(t, "service.local", outCtxt.Hostname)
+	require.Equal(t, 8080, outCtxt.Port)
+	require.Equal(t, "secret", outCtxt.CustomProperties["TOKEN"])
+	require.Equal(t, "docker-fleet-agent", outCtxt.Agent.Host.NamePrefix)
+
+	require.NoError(t, service.Signal("SIGHUP"))
+	signal, err := ioutil.ReadFile(filepath.Join(dir, "signal.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "SIGHUP 12345\n", string(signal))
+
+	logs, err := service.Logs()
+	require.NoError(t, err)
+	require.Equal(t, "service logs\n", string(logs))
+
+	require.NoError(t, service.TearDown())
+	teardown, err := ioutil.ReadFile(filepath.Join(dir, "teardown.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(teardown), `"hostname":"service.local"`)
+}
+
+func TestCustomServiceDeployer_invalidSetUpOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported")
+	}
+
+	dir, err := ioutil.TempDir("", "custom")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	script := strings.Replace(customDeployerScript, `echo '{"hostname"`, `echo 'deployed'; echo '{"hostname"`, 1)
+	err = ioutil.WriteFile(filepath.Join(dir, customDeployerExecutable), []byte(script), 0755)
+	require.NoError(t, err)
+
+	deployer, err := NewCustomServiceDeployer(dir)
+	require.NoError(t, err)
+
+	var ctxt ServiceContext
+	ctxt.Name = "service"
+
+	_, err = deployer.SetUp(ctxt)
+	require.Error(t, err)
+
+	// The service is torn down with the original context.
+	teardown, err := ioutil.ReadFile(filepath.Join(dir, "teardown.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(teardown), `"name":"service"`)
+}
@@ -12,6 +12,9 @@ type DeployedService interface {
 	// Signal sends a signal to the service.
 	Signal(signal string) error
 
+	// Logs returns logs produced by the service.
+	Logs() ([]byte, error)
+
 	// Context returns the current context from the service.
 	Context() ServiceContext
 
@@ -9,8 +9,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/profile"
 )
 
 const devDeployDir = "_dev/deploy"
@@ -19,6 +23,21 @@ const devDeployDir = "_dev/deploy"
 type FactoryOptions struct {
 	PackageRootPath    string
 	DataStreamRootPath string
+
+	// ServicePodSelector is a label selector of Kubernetes pods running the service.
+	ServicePodSelector string
+
+	// ServiceReadyTimeout limits the time to wait for healthy service containers (Docker Compose, Terraform).
+	ServiceReadyTimeout time.Duration
+
+	// KeepTerraformState disables removing the Terraform workspace once the service is torn down.
+	KeepTerraformState bool
+
+	// Profile is the profile of the Elastic stack the service is connected to (optional).
+	Profile *profile.Profile
+
+	// DeployerName selects the service deployer, if multiple ones are defined (e.g. docker, k8s, tf).
+	DeployerName string
 }
 
 // Factory chooses the appropriate service runner for the given data stream, depending
@@ -29,7 +48,7 @@ func Factory(options FactoryOptions) (ServiceDeployer, error) {
 		return nil, errors.Wrapf(err, "can't find \"%s\" directory", devDeployDir)
 	}
 
-	serviceDeployerName, err := findServiceDeployer(devDeployPath)
+	serviceDeployerName, err := findServiceDeployer(devDeployPath, options.DeployerName)
 	if err != nil {
 		return nil, errors.Wrap(err, "can't find any valid service deployer")
 	}
@@ -39,16 +58,26 @@ func Factory(options FactoryOptions) (ServiceDeployer, error) {
 	switch serviceDeployerName {
 	case "k8s":
 		if _, err := os.Stat(serviceDeployerPath); err == nil {
-			return NewKubernetesServiceDeployer(serviceDeployerPath)
+			return NewKubernetesServiceDeployer(serviceDeployerPath, options.ServicePodSelector, options.Profile)
 		}
 	case "docker":
 		dockerComposeYMLPath := filepath.Join(serviceDeployerPath, "docker-compose.yml")
 		if _, err := os.Stat(dockerComposeYMLPath); err == nil {
-			return NewDockerComposeServiceDeployer([]string{dockerComposeYMLPath})
+			return NewDockerComposeServiceDeployer([]string{dockerComposeYMLPath}, options.ServiceReadyTimeout)
+		}
+	case "mock":
+		mockConfigPath := filepath.Join(serviceDeployerPath, mockConfigFile)
+		if _, err := os.Stat(mockConfigPath); err == nil {
+			return NewMockServiceDeployer(serviceDeployerPath)
+		}
+	case "custom":
+		deployerPath := filepath.Join(serviceDeployerPath, customDeployerExecutable)
+		if _, err := os.Stat(deployerPath); err == nil {
+			return NewCustomServiceDeployer(serviceDeployerPath)
 		}
 	case "tf":
 		if _, err := os.Stat(serviceDeployerPath); err == nil {
-			return NewTerraformServiceDeployer(serviceDeployerPath)
+			return NewTerraformServiceDeployer(serviceDeployerPath, options.ServiceReadyTimeout, options.KeepTerraformState)
 		}
 	}
 	return nil, fmt.Errorf("unsupported service deployer (name: %s)", serviceDeployerName)
@@ -73,21 +102,33 @@ func findDevDeployPath(options FactoryOptions) (string, error) {
 	return "", fmt.Errorf("\"%s\" directory doesn't exist", devDeployDir)
 }
 
-func findServiceDeployer(devDeployPath string) (string, error) {
+// findServiceDeployer returns the name of the selected service deployer. If no deployer is selected,
+// the directory must contain only one.
+func findServiceDeployer(devDeployPath, selected string) (string, error) {
 	fis, err := ioutil.ReadDir(devDeployPath)
 	if err != nil {
 		return "", errors.Wrapf(err, "can't read directory (path: %s)", devDeployDir)
 	}
 
-	var folders []os.FileInfo
+	var folders []string
 	for _, fi := range fis {
 		if fi.IsDir() {
-			folders = append(folders, fi)
+			folders = append(folders, fi.Name())
+		}
+	}
+
+	if selected != "" {
+		for _, folder := range folders {
+			if folder == selected {
+				return folder, nil
+			}
 		}
+		return "", fmt.Errorf("selected service deployer \"%s\" not found in \"%s\"", selected, devDeployPath)
 	}
 
 	if len(folders) != 1 {
-		return "", fmt.Errorf("expected to find only one service deployer in \"%s\"", devDeployPath)
+		return "", fmt.Errorf("expected to find only one service deployer in \"%s\" (found: %s), select one with the \"deployer\" option",
+			devDeployPath, strings.Join(folders, ", "))
 	}
-	return folders[0].Name(), nil
+	return folders[0], nil
 }
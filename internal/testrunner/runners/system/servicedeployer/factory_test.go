@@ -0,0 +1,38 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindServiceDeployer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dev-deploy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "docker"), 0755))
+
+	name, err := findServiceDeployer(dir, "")
+	require.NoError(t, err)
+	require.Equal(t, "docker", name)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "tf"), 0755))
+
+	_, err = findServiceDeployer(dir, "")
+	require.Error(t, err)
+
+	name, err = findServiceDeployer(dir, "tf")
+	require.NoError(t, err)
+	require.Equal(t, "tf", name)
+
+	_, err = findServiceDeployer(dir, "k8s")
+	require.Error(t, err)
+}
@@ -5,58 +5,96 @@
 package servicedeployer
 
 import (
-	"io/ioutil"
-	"path/filepath"
+	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
 
-	"github.com/elastic/elastic-package/internal/configuration/locations"
 	"github.com/elastic/elastic-package/internal/kind"
 	"github.com/elastic/elastic-package/internal/kubectl"
 	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/multierror"
+	"github.com/elastic/elastic-package/internal/profile"
 )
 
+// kubernetesNamespaceVar is the name of the template variable holding the test namespace.
+const kubernetesNamespaceVar = "KUBERNETES_NAMESPACE"
+
 // KubernetesServiceDeployer is responsible for deploying resources in the Kubernetes cluster.
 type KubernetesServiceDeployer struct {
 	definitionsDir string
+	podSelector    string
+	profile        *profile.Profile
 }
 
 type kubernetesDeployedService struct {
 	ctxt ServiceContext
 
-	definitionsDir string
+	namespace   string
+	podSelector string
+
+	// definitions are rendered custom definitions applied to the cluster.
+	definitions []byte
 }
 
+// TearDown deletes resources created from custom definitions, including cluster-scoped ones and ones
+// in other namespaces, and the test namespace.
 func (s kubernetesDeployedService) TearDown() error {
-	logger.Debugf("uninstall custom Kubernetes definitions (directory: %s)", s.definitionsDir)
+	logger.Debugf("uninstall custom Kubernetes definitions (namespace: %s)", s.namespace)
+
+	var errs multierror.Error
+	if len(s.definitions) > 0 {
+		err := kubectl.DeleteStdin(s.definitions, s.namespace)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "can't delete resources created from custom definitions"))
+		}
+	}
 
-	definitionPaths, err := findKubernetesDefinitions(s.definitionsDir)
+	err := kubectl.DeleteNamespace(s.namespace)
 	if err != nil {
-		return errors.Wrapf(err, "can't find Kubernetes definitions in given directory (path: %s)", s.definitionsDir)
+		errs = append(errs, errors.Wrapf(err, "can't delete test namespace (name: %s)", s.namespace))
 	}
 
-	if len(definitionPaths) == 0 {
-		logger.Debugf("no custom definitions found (directory: %s). Nothing will be uninstalled.", s.definitionsDir)
-		return nil
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Signal sends the signal to the main process of every pod matching the pod selector.
+func (s kubernetesDeployedService) Signal(signal string) error {
+	if s.podSelector == "" {
+		return errors.New("service pod selector is required to send signals")
 	}
 
-	err = kubectl.Delete(definitionPaths...)
+	pods, err := kubectl.FindPods(s.namespace, s.podSelector)
 	if err != nil {
-		return errors.Wrapf(err, "can't uninstall Kubernetes resources (path: %s)", s.definitionsDir)
+		return errors.Wrapf(err, "can't find pods (selector: %s)", s.podSelector)
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("no pods found (namespace: %s, selector: %s)", s.namespace, s.podSelector)
+	}
+
+	for _, pod := range pods {
+		logger.Debugf("send signal %s to pod %s", signal, pod)
+		err = kubectl.Exec(pod, "kill", "-"+strings.TrimPrefix(signal, "SIG"), "1")
+		if err != nil {
+			return errors.Wrapf(err, "can't send signal to pod (pod: %s)", pod)
+		}
 	}
 	return nil
 }
 
-func (s kubernetesDeployedService) Signal(_ string) error {
-	return errors.New("signal is not supported")
+// Logs returns logs of pods in the test namespace, optionally matching the pod selector.
+func (s kubernetesDeployedService) Logs() ([]byte, error) {
+	return kubectl.Logs(s.namespace, s.podSelector)
 }
 
 func (s kubernetesDeployedService) Context() ServiceContext {
 	return s.ctxt
 }
 
-func (s kubernetesDeployedService) SetContext(sc ServiceContext) error {
+func (s *kubernetesDeployedService) SetContext(sc ServiceContext) error {
 	s.ctxt = sc
 	return nil
 }
@@ -64,9 +102,13 @@ func (s kubernetesDeployedService) SetContext(sc ServiceContext) error {
 var _ DeployedService = new(kubernetesDeployedService)
 
 // NewKubernetesServiceDeployer function creates a new instance of KubernetesServiceDeployer.
-func NewKubernetesServiceDeployer(definitionsDir string) (*KubernetesServiceDeployer, error) {
+// The pod selector is a label selector of pods receiving signals and providing logs (optional).
+// The profile of the Elastic stack decides whether Elastic Agent enrolls over HTTPS (optional).
+func NewKubernetesServiceDeployer(definitionsDir, podSelector string, elasticStackProfile *profile.Profile) (*KubernetesServiceDeployer, error) {
 	return &KubernetesServiceDeployer{
 		definitionsDir: definitionsDir,
+		podSelector:    podSelector,
+		profile:        elasticStackProfile,
 	}, nil
 }
 
@@ -83,14 +125,20 @@ func (ksd KubernetesServiceDeployer) SetUp(ctxt ServiceContext) (DeployedService
 		return nil, errors.Wrap(err, "can't connect control plane to Elastic stack network")
 	}
 
-	err = installElasticAgentInCluster()
+	err = installElasticAgentInCluster(ksd.profile)
 	if err != nil {
 		return nil, errors.Wrap(err, "can't install Elastic-Agent in the Kubernetes cluster")
 	}
 
-	err = ksd.installCustomDefinitions()
+	namespace := kubectl.TestNamespace(ctxt.Test.RunID)
+	err = kubectl.CreateNamespace(namespace, map[string]string{kubectl.TestRunLabel: ctxt.Test.RunID})
 	if err != nil {
-		return nil, errors.Wrap(err, "can't install custom definitions in the Kubernetes cluster")
+		return nil, errors.Wrap(err, "can't create test namespace")
+	}
+
+	service := &kubernetesDeployedService{
+		namespace:   namespace,
+		podSelector: ksd.podSelector,
 	}
 
 	ctxt.Name = kind.ControlPlaneContainerName
@@ -98,26 +146,37 @@ func (ksd KubernetesServiceDeployer) SetUp(ctxt ServiceContext) (DeployedService
 	// kind-control-plane is the name of the kind host where Pod is running since we use hostNetwork setting
 	// to deploy Agent Pod. Because of this, hostname inside pod will be equal to the name of the k8s host.
 	ctxt.Agent.Host.NamePrefix = "kind-control-plane"
-	return &kubernetesDeployedService{
-		ctxt:           ctxt,
-		definitionsDir: ksd.definitionsDir,
-	}, nil
+	ctxt.CustomProperties = map[string]interface{}{
+		kubernetesNamespaceVar: namespace,
+	}
+	service.ctxt = ctxt
+
+	err = ksd.installCustomDefinitions(service)
+	if err != nil {
+		if tdErr := service.TearDown(); tdErr != nil {
+			logger.Errorf("can't remove custom definitions: %v", tdErr)
+		}
+		return nil, errors.Wrap(err, "can't install custom definitions in the Kubernetes cluster")
+	}
+	return service, nil
 }
 
-func (ksd KubernetesServiceDeployer) installCustomDefinitions() error {
-	logger.Debugf("install custom Kubernetes definitions (directory: %s)", ksd.definitionsDir)
+func (ksd KubernetesServiceDeployer) installCustomDefinitions(service *kubernetesDeployedService) error {
+	logger.Debugf("install custom Kubernetes definitions (directory: %s, namespace: %s)", ksd.definitionsDir, service.namespace)
 
-	definitionPaths, err := findKubernetesDefinitions(ksd.definitionsDir)
+	definitions, err := renderCustomDefinitions(ksd.definitionsDir, service.ctxt, service.namespace)
 	if err != nil {
-		return errors.Wrapf(err, "can't find Kubernetes definitions in given directory (path: %s)", ksd.definitionsDir)
+		return errors.Wrapf(err, "can't render custom definitions (path: %s)", ksd.definitionsDir)
 	}
 
-	if len(definitionPaths) == 0 {
+	if len(definitions) == 0 {
 		logger.Debugf("no custom definitions found (directory: %s). Nothing else will be installed.", ksd.definitionsDir)
 		return nil
 	}
 
-	err = kubectl.Apply(definitionPaths...)
+	// Definitions are kept before applying them, so partially applied ones are removed too.
+	service.definitions = definitions
+	err = kubectl.ApplyStdin(definitions, service.namespace)
 	if err != nil {
 		return errors.Wrap(err, "can't install custom definitions")
 	}
@@ -125,33 +184,3 @@ func (ksd KubernetesServiceDeployer) installCustomDefinitions() error {
 }
 
 var _ ServiceDeployer = new(KubernetesServiceDeployer)
-
-func findKubernetesDefinitions(definitionsDir string) ([]string, error) {
-	fileInfos, err := ioutil.ReadDir(definitionsDir)
-	if err != nil {
-		return nil, errors.Wrapf(err, "can't read definitions directory (path: %s)", definitionsDir)
-	}
-
-	var definitionPaths []string
-	for _, fileInfo := range fileInfos {
-		if strings.HasSuffix(fileInfo.Name(), ".yaml") {
-			definitionPaths = append(definitionPaths, filepath.Join(definitionsDir, fileInfo.Name()))
-		}
-	}
-	return definitionPaths, nil
-}
-
-func installElasticAgentInCluster() error {
-	logger.Debug("install Elastic Agent in the Kubernetes cluster")
-
-	locationManager, err := locations.NewLocationManager()
-	if err != nil {
-		return errors.Wrap(err, "can't locate Kubernetes file for Elastic Agent in ")
-	}
-
-	err = kubectl.Apply(locationManager.KubernetesDeployerAgentYml())
-	if err != nil {
-		return errors.Wrap(err, "can't install Elastic-Agent in Kubernetes cluster")
-	}
-	return nil
-}
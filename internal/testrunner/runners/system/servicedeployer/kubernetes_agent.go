@@ -0,0 +1,212 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+	"github.com/elastic/elastic-package/internal/kubectl"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+const (
+	kubernetesAgentDeploymentName = "kind-fleet-agent-clusterscope"
+	kubernetesAgentNamespace      = "kube-system"
+	kubernetesAgentCASecretName   = "kind-fleet-agent-ca"
+	kubernetesAgentCAVolumeName   = "elastic-package-ca"
+
+	// Paths match the ones used by Elastic Agents of the Elastic stack.
+	kubernetesAgentTLSDir       = "/etc/elastic-package/tls"
+	kubernetesAgentSystemCAPath = "/etc/ssl/certs/elastic-package-ca.pem"
+
+	caCertificateFile = "ca-cert.pem"
+)
+
+func installElasticAgentInCluster(elasticStackProfile *profile.Profile) error {
+	logger.Debug("install Elastic Agent in the Kubernetes cluster")
+
+	locationManager, err := locations.NewLocationManager()
+	if err != nil {
+		return errors.Wrap(err, "can't locate Kubernetes file for Elastic Agent in ")
+	}
+
+	definitionsPath := locationManager.KubernetesDeployerAgentYml()
+	definitions, err := ioutil.ReadFile(definitionsPath)
+	if err != nil {
+		return errors.Wrapf(err, "can't read Elastic Agent definitions (path: %s)", definitionsPath)
+	}
+
+	if elasticStackProfile != nil {
+		config, err := elasticStackProfile.Config()
+		if err != nil {
+			return errors.Wrap(err, "can't read profile configuration")
+		}
+
+		if config.Stack.TLS.Enabled {
+			caCert, err := ioutil.ReadFile(elasticStackProfile.CACertificatePath())
+			if err != nil {
+				return errors.Wrap(err, "can't read CA certificate of the profile")
+			}
+
+			definitions, err = enableElasticAgentTLS(definitions, caCert)
+			if err != nil {
+				return errors.Wrap(err, "can't configure TLS of Elastic Agent")
+			}
+		}
+	}
+
+	err = kubectl.ApplyStdin(definitions, "")
+	if err != nil {
+		return errors.Wrap(err, "can't install Elastic-Agent in Kubernetes cluster")
+	}
+	return nil
+}
+
+// enableElasticAgentTLS configures the Elastic Agent deployment to enroll in Fleet Server over HTTPS.
+// The CA certificate is stored in a secret and mounted in the same locations as in Elastic Agents
+// of the Elastic stack, so the agent trusts also outputs signed by the CA.
+func enableElasticAgentTLS(definitions, caCert []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(definitions))
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	var found bool
+	for {
+		var document map[string]interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "can't decode definitions")
+		}
+		if document == nil {
+			continue
+		}
+
+		if isElasticAgentDeployment(document) {
+			err = configureElasticAgentTLS(document)
+			if err != nil {
+				return nil, errors.Wrap(err, "can't configure Elastic Agent deployment")
+			}
+			found = true
+		}
+
+		err = encoder.Encode(document)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't encode definitions")
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("deployment %s not found", kubernetesAgentDeploymentName)
+	}
+
+	err := encoder.Encode(elasticAgentCASecret(caCert))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't encode CA secret")
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't encode definitions")
+	}
+	return b.Bytes(), nil
+}
+
+func isElasticAgentDeployment(document map[string]interface{}) bool {
+	metadata, _ := document["metadata"].(map[string]interface{})
+	return document["kind"] == "Deployment" && metadata["name"] == kubernetesAgentDeploymentName
+}
+
+func configureElasticAgentTLS(deployment map[string]interface{}) error {
+	spec, _ := deployment["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	podSpec, ok := template["spec"].(map[string]interface{})
+	if !ok {
+		return errors.New("pod template not found")
+	}
+
+	containers, _ := podSpec["containers"].([]interface{})
+	if len(containers) == 0 {
+		return errors.New("containers not found")
+	}
+
+	agentCA := path.Join(kubernetesAgentTLSDir, caCertificateFile)
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			return errors.New("invalid container definition")
+		}
+
+		env, _ := container["env"].([]interface{})
+		env = setEnvVar(env, "FLEET_INSECURE", "0")
+		env = setEnvVar(env, "FLEET_URL", "https://fleet-server:8220")
+		env = setEnvVar(env, "FLEET_CA", agentCA)
+		container["env"] = env
+
+		volumeMounts, _ := container["volumeMounts"].([]interface{})
+		container["volumeMounts"] = append(volumeMounts,
+			caVolumeMount(agentCA),
+			caVolumeMount(kubernetesAgentSystemCAPath))
+	}
+
+	volumes, _ := podSpec["volumes"].([]interface{})
+	podSpec["volumes"] = append(volumes, map[string]interface{}{
+		"name": kubernetesAgentCAVolumeName,
+		"secret": map[string]interface{}{
+			"secretName": kubernetesAgentCASecretName,
+		},
+	})
+	return nil
+}
+
+// setEnvVar sets the value of the environment variable, replacing the existing definition if any.
+func setEnvVar(env []interface{}, name, value string) []interface{} {
+	for _, e := range env {
+		if v, ok := e.(map[string]interface{}); ok && v["name"] == name {
+			delete(v, "valueFrom")
+			v["value"] = value
+			return env
+		}
+	}
+	return append(env, map[string]interface{}{"name": name, "value": value})
+}
+
+func caVolumeMount(mountPath string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      kubernetesAgentCAVolumeName,
+		"mountPath": mountPath,
+		"subPath":   caCertificateFile,
+		"readOnly":  true,
+	}
+}
+
+func elasticAgentCASecret(caCert []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      kubernetesAgentCASecretName,
+			"namespace": kubernetesAgentNamespace,
+			"labels": map[string]interface{}{
+				"group": "fleet",
+			},
+		},
+		"stringData": map[string]interface{}{
+			caCertificateFile: string(caCert),
+		},
+	}
+}
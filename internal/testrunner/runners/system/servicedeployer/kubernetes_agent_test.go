@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testElasticAgentDefinitions = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kind-fleet-agent-clusterscope
+  namespace: kube-system
+spec:
+  template:
+    spec:
+      containers:
+        - name: kind-fleet-agent-clusterscope
+          env:
+            - name: FLEET_INSECURE
+              value: "1"
+            - name: FLEET_URL
+              value: "http://fleet-server:8220"
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kind-fleet-agent
+  namespace: kube-system
+`
+
+func TestEnableElasticAgentTLS(t *testing.T) {
+	definitions, err := enableElasticAgentTLS([]byte(testElasticAgentDefinitions), []byte("CA certificate"))
+	require.NoError(t, err)
+
+	var documents []map[string]interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(definitions))
+	for {
+		var document map[string]interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		documents = append(documents, document)
+	}
+	require.Len(t, documents, 3)
+
+	podSpec := documents[0]["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	container := podSpec["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "FLEET_INSECURE", "value": "0"},
+		map[string]interface{}{"name": "FLEET_URL", "value": "https://fleet-server:8220"},
+		map[string]interface{}{"name": "FLEET_CA", "value": "/etc/elastic-package/tls/ca-cert.pem"},
+	}, container["env"])
+	assert.Len(t, container["volumeMounts"], 2)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "elastic-package-ca", "secret": map[string]interface{}{"secretName": "kind-fleet-agent-ca"}},
+	}, podSpec["volumes"])
+
+	assert.Equal(t, "ServiceAccount", documents[1]["kind"])
+
+	assert.Equal(t, "Secret", documents[2]["kind"])
+	assert.Equal(t, map[string]interface{}{"ca-cert.pem": "CA certificate"}, documents[2]["stringData"])
+}
+
+func TestEnableElasticAgentTLS_deploymentNotFound(t *testing.T) {
+	_, err := enableElasticAgentTLS([]byte("apiVersion: v1\nkind: ServiceAccount\n"), []byte("CA certificate"))
+	require.Error(t, err)
+}
@@ -0,0 +1,215 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aymerick/raymond"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+
+	"github.com/elastic/elastic-package/internal/kubectl"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const (
+	kustomizationFile  = "kustomization.yaml"
+	helmDescriptorFile = "helm.yml"
+
+	defaultHelmReleaseName = "elastic-package-service"
+
+	definitionFileSuffix         = ".yaml"
+	definitionTemplateFileSuffix = ".yaml.hbs"
+)
+
+// helmDescriptor describes the Helm chart deployed by the Kubernetes service deployer.
+type helmDescriptor struct {
+	// Chart is the path to the chart directory or archive, relative to the definitions directory.
+	Chart string `yaml:"chart"`
+
+	// Values is the path to the values file, relative to the definitions directory (optional).
+	// The file may contain placeholders of the service context, e.g. {{KUBERNETES_NAMESPACE}}.
+	Values string `yaml:"values"`
+
+	// ReleaseName is the name of the release used while rendering the chart (optional).
+	ReleaseName string `yaml:"release_name"`
+}
+
+// renderCustomDefinitions renders manifests defined in the definitions directory. The directory
+// can contain a kustomization, a Helm descriptor or raw *.yaml definitions and *.yaml.hbs templates.
+func renderCustomDefinitions(definitionsDir string, ctxt ServiceContext, namespace string) ([]byte, error) {
+	kustomizationPath := filepath.Join(definitionsDir, kustomizationFile)
+	_, err := os.Stat(kustomizationPath)
+	if err == nil {
+		logger.Debugf("render kustomization (path: %s)", kustomizationPath)
+		return kubectl.Kustomize(definitionsDir)
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat failed (path: %s)", kustomizationPath)
+	}
+
+	helmDescriptorPath := filepath.Join(definitionsDir, helmDescriptorFile)
+	_, err = os.Stat(helmDescriptorPath)
+	if err == nil {
+		logger.Debugf("render Helm chart (descriptor: %s)", helmDescriptorPath)
+		return renderHelmChart(definitionsDir, ctxt, namespace)
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat failed (path: %s)", helmDescriptorPath)
+	}
+
+	definitionPaths, err := findKubernetesDefinitions(definitionsDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't find Kubernetes definitions in given directory (path: %s)", definitionsDir)
+	}
+	return renderKubernetesDefinitions(definitionPaths, ctxt)
+}
+
+func renderHelmChart(definitionsDir string, ctxt ServiceContext, namespace string) ([]byte, error) {
+	descriptorPath := filepath.Join(definitionsDir, helmDescriptorFile)
+	data, err := ioutil.ReadFile(descriptorPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read Helm descriptor (path: %s)", descriptorPath)
+	}
+
+	var descriptor helmDescriptor
+	err = yaml.Unmarshal(data, &descriptor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't unmarshal Helm descriptor (path: %s)", descriptorPath)
+	}
+	if descriptor.Chart == "" {
+		return nil, errors.Errorf("chart is not defined in Helm descriptor (path: %s)", descriptorPath)
+	}
+	if descriptor.ReleaseName == "" {
+		descriptor.ReleaseName = defaultHelmReleaseName
+	}
+
+	chart, err := loader.Load(filepath.Join(definitionsDir, descriptor.Chart))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't load Helm chart (path: %s)", descriptor.Chart)
+	}
+
+	values := map[string]interface{}{}
+	if descriptor.Values != "" {
+		valuesPath := filepath.Join(definitionsDir, descriptor.Values)
+		rendered, err := renderTemplateFile(valuesPath, ctxt)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't render Helm values")
+		}
+
+		values, err = chartutil.ReadValues(rendered)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't read Helm values (path: %s)", valuesPath)
+		}
+	}
+
+	renderValues, err := chartutil.ToRenderValues(chart, values, chartutil.ReleaseOptions{
+		Name:      descriptor.ReleaseName,
+		Namespace: namespace,
+		IsInstall: true,
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't prepare Helm values")
+	}
+
+	manifests, err := engine.Render(chart, renderValues)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't render Helm chart")
+	}
+	return joinHelmManifests(manifests), nil
+}
+
+// joinHelmManifests joins manifests rendered by Helm into a single multi-document YAML in
+// a deterministic order. Notes and partials don't contain manifests, so they are skipped.
+func joinHelmManifests(manifests map[string]string) []byte {
+	var names []string
+	for name, manifest := range manifests {
+		base := filepath.Base(name)
+		if strings.HasPrefix(base, "_") || base == "NOTES.txt" || strings.TrimSpace(manifest) == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		b.WriteString("---\n")
+		b.WriteString(manifests[name])
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+func findKubernetesDefinitions(definitionsDir string) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(definitionsDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read definitions directory (path: %s)", definitionsDir)
+	}
+
+	var definitionPaths []string
+	for _, fileInfo := range fileInfos {
+		name := fileInfo.Name()
+		if strings.HasSuffix(name, definitionFileSuffix) || strings.HasSuffix(name, definitionTemplateFileSuffix) {
+			definitionPaths = append(definitionPaths, filepath.Join(definitionsDir, fileInfo.Name()))
+		}
+	}
+	return definitionPaths, nil
+}
+
+// renderKubernetesDefinitions joins definitions into a single multi-document YAML. The service context,
+// e.g. the test namespace, is applied only to templates (*.yaml.hbs), so raw definitions may contain
+// "{{" (e.g. Go templates in annotations).
+func renderKubernetesDefinitions(definitionPaths []string, ctxt ServiceContext) ([]byte, error) {
+	var b bytes.Buffer
+	for _, definitionPath := range definitionPaths {
+		var rendered []byte
+		var err error
+		if strings.HasSuffix(definitionPath, definitionTemplateFileSuffix) {
+			rendered, err = renderTemplateFile(definitionPath, ctxt)
+			if err != nil {
+				return nil, errors.Wrap(err, "can't render definition")
+			}
+		} else {
+			rendered, err = ioutil.ReadFile(definitionPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "can't read definition (path: %s)", definitionPath)
+			}
+		}
+
+		b.WriteString("---\n")
+		b.Write(rendered)
+		b.WriteString("\n")
+	}
+	return b.Bytes(), nil
+}
+
+func renderTemplateFile(path string, ctxt ServiceContext) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read file (path: %s)", path)
+	}
+
+	tmpl, err := raymond.Parse(string(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing template failed (path: %s)", path)
+	}
+	tmpl.RegisterHelpers(ctxt.Aliases())
+
+	rendered, err := tmpl.Exec(ctxt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rendering template failed (path: %s)", path)
+	}
+	return []byte(rendered), nil
+}
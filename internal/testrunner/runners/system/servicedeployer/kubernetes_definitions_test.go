@@ -0,0 +1,109 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCustomDefinitions_helmChart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k8s-definitions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, helmDescriptorFile), "chart: redis\nvalues: values.yml\n")
+	writeFile(t, filepath.Join(dir, "values.yml"), "namespace: {{KUBERNETES_NAMESPACE}}\n")
+	writeFile(t, filepath.Join(dir, "redis", "Chart.yaml"), "apiVersion: v2\nname: redis\nversion: 0.1.0\n")
+	writeFile(t, filepath.Join(dir, "redis", "templates", "NOTES.txt"), "Redis installed.\n")
+	writeFile(t, filepath.Join(dir, "redis", "templates", "configmap.yaml"), `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+data:
+  namespace: {{ .Values.namespace }}
+`)
+
+	var ctxt ServiceContext
+	ctxt.CustomProperties = map[string]interface{}{
+		kubernetesNamespaceVar: "elastic-package-test-12345",
+	}
+
+	definitions, err := renderCustomDefinitions(dir, ctxt, "elastic-package-test-12345")
+	require.NoError(t, err)
+	require.Equal(t, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: elastic-package-service
+  namespace: elastic-package-test-12345
+data:
+  namespace: elastic-package-test-12345
+
+`, string(definitions))
+}
+
+func TestRenderCustomDefinitions_rawDefinitionsAndTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k8s-definitions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "configmap.yaml"), `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: nginx
+data:
+  format: "{{ .Status }}"
+`)
+	writeFile(t, filepath.Join(dir, "rolebinding.yaml.hbs"), `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: nginx
+subjects:
+  - kind: ServiceAccount
+    name: nginx
+    namespace: {{KUBERNETES_NAMESPACE}}
+`)
+	writeFile(t, filepath.Join(dir, ".empty"), "")
+
+	var ctxt ServiceContext
+	ctxt.CustomProperties = map[string]interface{}{
+		kubernetesNamespaceVar: "elastic-package-test-12345",
+	}
+
+	definitions, err := renderCustomDefinitions(dir, ctxt, "elastic-package-test-12345")
+	require.NoError(t, err)
+	require.Equal(t, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: nginx
+data:
+  format: "{{ .Status }}"
+
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: nginx
+subjects:
+  - kind: ServiceAccount
+    name: nginx
+    namespace: elastic-package-test-12345
+
+`, string(definitions))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err)
+}
@@ -0,0 +1,385 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const (
+	mockConfigFile = "config.yml"
+
+	// dockerHostName is the name of the Docker host, as addressable from containers on macOS and Windows.
+	dockerHostName = "host.docker.internal"
+)
+
+// MockServiceDeployer runs a built-in mock service (HTTP server, replayers of log files) inside
+// the elastic-package process.
+type MockServiceDeployer struct {
+	definitionsDir string
+}
+
+type mockConfig struct {
+	HTTP    *mockHTTPConfig    `yaml:"http"`
+	Replays []mockReplayConfig `yaml:"replays"`
+}
+
+type mockHTTPConfig struct {
+	// Port the HTTP server listens on, random by default.
+	Port   int               `yaml:"port"`
+	Routes []mockRouteConfig `yaml:"routes"`
+}
+
+type mockRouteConfig struct {
+	Path       string            `yaml:"path"`
+	Methods    []string          `yaml:"methods"` // All methods are accepted by default.
+	StatusCode int               `yaml:"status_code"`
+	Headers    map[string]string `yaml:"headers"`
+	Body       string            `yaml:"body"`
+	BodyFile   string            `yaml:"body_file"` // Path relative to the mock directory.
+}
+
+type mockDeployedService struct {
+	ctxt ServiceContext
+
+	server  *http.Server
+	replays []*mockReplay
+	log     *mockLog
+
+	cancel context.CancelFunc
+	ctx    context.Context
+	wg     sync.WaitGroup
+}
+
+// NewMockServiceDeployer creates an instance of MockServiceDeployer.
+func NewMockServiceDeployer(definitionsDir string) (*MockServiceDeployer, error) {
+	return &MockServiceDeployer{
+		definitionsDir: definitionsDir,
+	}, nil
+}
+
+// SetUp starts the mock HTTP server and replays, which don't wait for a signal.
+func (d MockServiceDeployer) SetUp(inCtxt ServiceContext) (DeployedService, error) {
+	logger.Debug("setting up service using mock service deployer")
+
+	config, err := readMockConfig(d.definitionsDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read mock service configuration")
+	}
+
+	hostname, err := mockServiceHostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't determine hostname of the mock service")
+	}
+
+	// Clean service logs
+	err = files.RemoveContent(inCtxt.Logs.Folder.Local)
+	if err != nil {
+		return nil, errors.Wrap(err, "removing service logs failed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service := &mockDeployedService{
+		ctxt:   inCtxt,
+		log:    new(mockLog),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	outCtxt := inCtxt
+	outCtxt.Hostname = hostname
+
+	if config.HTTP != nil {
+		handler, err := newMockHandler(d.definitionsDir, config.HTTP.Routes, service.log)
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "can't create mock HTTP handler")
+		}
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(mockListenHost(hostname), strconv.Itoa(config.HTTP.Port)))
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "can't start mock HTTP server")
+		}
+
+		port := listener.Addr().(*net.TCPAddr).Port
+		outCtxt.Ports = []int{port}
+		outCtxt.Port = port
+
+		service.server = &http.Server{Handler: handler}
+		service.wg.Add(1)
+		go func() {
+			defer service.wg.Done()
+			err := service.server.Serve(listener)
+			if err != nil && err != http.ErrServerClosed {
+				service.log.Printf("HTTP server failed: %v", err)
+			}
+		}()
+		service.log.Printf("HTTP server listening on port %d", port)
+	}
+
+	for _, replayConfig := range config.Replays {
+		replay, err := newMockReplay(d.definitionsDir, replayConfig, outCtxt)
+		if err != nil {
+			if tdErr := service.TearDown(); tdErr != nil {
+				logger.Errorf("can't tear down mock service: %v", tdErr)
+			}
+			return nil, errors.Wrapf(err, "can't prepare replay (file: %s)", replayConfig.File)
+		}
+		service.replays = append(service.replays, replay)
+
+		if !replayConfig.WaitForSignal {
+			service.startReplay(replay)
+		}
+	}
+
+	outCtxt.Agent.Host.NamePrefix = "docker-fleet-agent"
+	service.ctxt = outCtxt
+	return service, nil
+}
+
+// TearDown stops the mock HTTP server and all replays.
+func (s *mockDeployedService) TearDown() error {
+	logger.Debug("tearing down mock service")
+	defer func() {
+		err := files.RemoveContent(s.ctxt.Logs.Folder.Local)
+		if err != nil {
+			logger.Errorf("could not remove the service logs (path: %s)", s.ctxt.Logs.Folder.Local)
+		}
+	}()
+
+	s.cancel()
+	if s.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := s.server.Shutdown(ctx)
+		if err != nil {
+			return errors.Wrap(err, "can't shut down mock HTTP server")
+		}
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// Signal starts replays waiting for a signal.
+func (s *mockDeployedService) Signal(signal string) error {
+	s.log.Printf("received signal %s", signal)
+	for _, replay := range s.replays {
+		s.startReplay(replay)
+	}
+	return nil
+}
+
+// Logs returns the log of requests served and replays.
+func (s *mockDeployedService) Logs() ([]byte, error) {
+	return s.log.Bytes(), nil
+}
+
+// Context returns the current context for the service.
+func (s *mockDeployedService) Context() ServiceContext {
+	return s.ctxt
+}
+
+// SetContext sets the current context for the service.
+func (s *mockDeployedService) SetContext(ctxt ServiceContext) error {
+	s.ctxt = ctxt
+	return nil
+}
+
+func (s *mockDeployedService) startReplay(replay *mockReplay) {
+	if replay.started {
+		return
+	}
+	replay.started = true
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := replay.run(s.ctx, s.log)
+		if err != nil {
+			s.log.Printf("replay of %s failed: %v", replay.config.File, err)
+		}
+	}()
+}
+
+func readMockConfig(definitionsDir string) (*mockConfig, error) {
+	configPath := filepath.Join(definitionsDir, mockConfigFile)
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read file (path: %s)", configPath)
+	}
+
+	var config mockConfig
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't unmarshal mock configuration (path: %s)", configPath)
+	}
+
+	if config.HTTP == nil && len(config.Replays) == 0 {
+		return nil, errors.New("neither HTTP server nor replays are defined")
+	}
+	for _, replay := range config.Replays {
+		err = replay.validate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid replay (file: %s)", replay.File)
+		}
+	}
+	return &config, nil
+}
+
+// mockServiceHostname returns the address of the machine running elastic-package, as addressable from
+// the Agent container. On Linux it's the gateway of the Elastic stack network.
+func mockServiceHostname() (string, error) {
+	if runtime.GOOS != "linux" {
+		return dockerHostName, nil
+	}
+
+	network, err := inspectStackNetwork()
+	if err != nil {
+		return "", err
+	}
+	if len(network.IPAM.Config) == 0 || network.IPAM.Config[0].Gateway == "" {
+		return "", fmt.Errorf("gateway of the %s network not found", stack.Network())
+	}
+	return network.IPAM.Config[0].Gateway, nil
+}
+
+// mockListenHost returns the address the mock HTTP server binds to, so it's reachable only from the Agent container
+// and the machine running elastic-package. On Linux it's the gateway of the Elastic stack network, Docker Desktop
+// forwards connections to host.docker.internal to the loopback interface.
+func mockListenHost(hostname string) string {
+	if runtime.GOOS != "linux" {
+		return "127.0.0.1"
+	}
+	return hostname
+}
+
+// elasticAgentAddress returns the IP address of the Elastic Agent container in the Elastic stack network.
+func elasticAgentAddress() (string, error) {
+	network, err := inspectStackNetwork()
+	if err != nil {
+		return "", err
+	}
+
+	agentContainer := stack.ServiceContainerName("elastic-agent")
+	for _, c := range network.Containers {
+		if c.Name == agentContainer {
+			return strings.SplitN(c.IPv4Address, "/", 2)[0], nil
+		}
+	}
+	return "", fmt.Errorf("container %s not found in the %s network", agentContainer, stack.Network())
+}
+
+func inspectStackNetwork() (*docker.NetworkDescription, error) {
+	networkDescriptions, err := docker.InspectNetwork(stack.Network())
+	if err != nil {
+		return nil, errors.Wrap(err, "can't inspect network")
+	}
+	if len(networkDescriptions) != 1 {
+		return nil, fmt.Errorf("expected single network description, got %d entries", len(networkDescriptions))
+	}
+	return &networkDescriptions[0], nil
+}
+
+type mockRoute struct {
+	config mockRouteConfig
+	body   []byte
+}
+
+func (r mockRoute) matches(req *http.Request) bool {
+	if r.config.Path != req.URL.Path {
+		return false
+	}
+	if len(r.config.Methods) == 0 {
+		return true
+	}
+	for _, method := range r.config.Methods {
+		if strings.EqualFold(method, req.Method) {
+			return true
+		}
+	}
+	return false
+}
+
+func newMockHandler(definitionsDir string, routeConfigs []mockRouteConfig, log *mockLog) (http.Handler, error) {
+	var routes []mockRoute
+	for _, config := range routeConfigs {
+		route := mockRoute{config: config, body: []byte(config.Body)}
+		if config.BodyFile != "" {
+			body, err := ioutil.ReadFile(filepath.Join(definitionsDir, config.BodyFile))
+			if err != nil {
+				return nil, errors.Wrapf(err, "can't read response body (path: %s)", config.BodyFile)
+			}
+			route.body = body
+		}
+		if route.config.StatusCode == 0 {
+			route.config.StatusCode = http.StatusOK
+		}
+		routes = append(routes, route)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, route := range routes {
+			if !route.matches(req) {
+				continue
+			}
+
+			for k, v := range route.config.Headers {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(route.config.StatusCode)
+			w.Write(route.body)
+			log.Printf("%s %s: %d", req.Method, req.URL, route.config.StatusCode)
+			return
+		}
+
+		http.NotFound(w, req)
+		log.Printf("%s %s: %d (no matching route)", req.Method, req.URL, http.StatusNotFound)
+	}), nil
+}
+
+// mockLog is an in-memory log of the mock service, safe for concurrent use.
+type mockLog struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *mockLog) Printf(format string, a ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fmt.Fprintf(&l.buf, "%s ", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&l.buf, format, a...)
+	l.buf.WriteString("\n")
+}
+
+func (l *mockLog) Bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]byte(nil), l.buf.Bytes()...)
+}
+
+var _ ServiceDeployer = new(MockServiceDeployer)
+var _ DeployedService = new(mockDeployedService)
@@ -0,0 +1,196 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	replayProtocolUDP  = "udp"
+	replayProtocolTCP  = "tcp"
+	replayProtocolFile = "file"
+
+	replayConnectRetryInterval = time.Second
+)
+
+type mockReplayConfig struct {
+	// File contains lines to replay, path relative to the mock directory.
+	File string `yaml:"file"`
+
+	// Protocol defines where lines are replayed: "udp" or "tcp" (to the port the Elastic Agent listens on),
+	// or "file" (to the file in the service logs directory).
+	Protocol string `yaml:"protocol"`
+
+	// Host is the address of the Elastic Agent (udp, tcp), its IP address in the stack network by default (Linux only).
+	Host string `yaml:"host"`
+	// Port the Elastic Agent listens on (udp, tcp).
+	Port int `yaml:"port"`
+
+	// Path is the name of the file in the service logs directory (file).
+	Path string `yaml:"path"`
+
+	// Rate is the number of lines replayed per second, unlimited by default.
+	Rate float64 `yaml:"rate"`
+	// Loop enables replaying the file repeatedly, until the service is torn down.
+	Loop bool `yaml:"loop"`
+	// WaitForSignal defers the replay until the service is signalled (see "service_notify_signal").
+	WaitForSignal bool `yaml:"wait_for_signal"`
+}
+
+func (c mockReplayConfig) validate() error {
+	if c.File == "" {
+		return errors.New("file is not defined")
+	}
+
+	switch c.Protocol {
+	case replayProtocolUDP, replayProtocolTCP:
+		if c.Port == 0 {
+			return fmt.Errorf("port is required for the %s protocol", c.Protocol)
+		}
+	case replayProtocolFile:
+		if c.Path == "" {
+			return errors.New("path is required for the file protocol")
+		}
+	default:
+		return fmt.Errorf("unsupported protocol \"%s\"", c.Protocol)
+	}
+	return nil
+}
+
+type mockReplay struct {
+	config mockReplayConfig
+	lines  [][]byte
+
+	// address is the target of udp and tcp replays, or the path of the file for file replays.
+	address string
+	started bool
+}
+
+func newMockReplay(definitionsDir string, config mockReplayConfig, ctxt ServiceContext) (*mockReplay, error) {
+	content, err := ioutil.ReadFile(filepath.Join(definitionsDir, config.File))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read replayed file (path: %s)", config.File)
+	}
+
+	replay := mockReplay{
+		config: config,
+		lines:  bytes.Split(bytes.TrimRight(content, "\n"), []byte("\n")),
+	}
+
+	switch config.Protocol {
+	case replayProtocolFile:
+		replay.address = filepath.Join(ctxt.Logs.Folder.Local, config.Path)
+	default:
+		host := config.Host
+		if host == "" {
+			// The elastic-package process can route to container IP addresses only if Docker runs natively (Linux),
+			// not in a virtual machine (Docker Desktop).
+			if runtime.GOOS != "linux" {
+				return nil, fmt.Errorf("host is required for %s replays on %s, as the Elastic Agent container isn't reachable by its IP address "+
+					"(publish the port of the Elastic Agent and set the host, e.g. \"localhost\")", config.Protocol, runtime.GOOS)
+			}
+			host, err = elasticAgentAddress()
+			if err != nil {
+				return nil, errors.Wrap(err, "can't find address of the Elastic Agent")
+			}
+		}
+		replay.address = net.JoinHostPort(host, strconv.Itoa(config.Port))
+	}
+	return &replay, nil
+}
+
+// run replays lines until all of them are sent (or forever, if looped) or the context is cancelled.
+func (r *mockReplay) run(ctx context.Context, log *mockLog) error {
+	w, err := r.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	var interval time.Duration
+	if r.config.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / r.config.Rate)
+	}
+
+	log.Printf("replaying %s to %s (%s)", r.config.File, r.address, r.config.Protocol)
+	for count := 0; ; {
+		for _, line := range r.lines {
+			err := r.write(w, line)
+			if err != nil {
+				return errors.Wrapf(err, "can't write line (sent: %d)", count)
+			}
+			count++
+
+			select {
+			case <-ctx.Done():
+				log.Printf("replay of %s interrupted (sent: %d)", r.config.File, count)
+				return nil
+			case <-time.After(interval):
+			}
+		}
+
+		if !r.config.Loop {
+			log.Printf("replay of %s completed (sent: %d)", r.config.File, count)
+			return nil
+		}
+	}
+}
+
+func (r *mockReplay) open(ctx context.Context) (io.WriteCloser, error) {
+	switch r.config.Protocol {
+	case replayProtocolFile:
+		f, err := os.OpenFile(r.address, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't open file (path: %s)", r.address)
+		}
+		return f, nil
+	case replayProtocolUDP:
+		conn, err := net.Dial("udp", r.address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't dial %s", r.address)
+		}
+		return conn, nil
+	}
+
+	// The Elastic Agent starts listening once the policy is applied, so keep trying to connect.
+	for {
+		conn, err := net.Dial("tcp", r.address)
+		if err == nil {
+			return conn, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(err, "can't connect to %s", r.address)
+		case <-time.After(replayConnectRetryInterval):
+		}
+	}
+}
+
+func (r *mockReplay) write(w io.Writer, line []byte) error {
+	if r.config.Protocol == replayProtocolUDP {
+		_, err := w.Write(line) // one datagram per line
+		return err
+	}
+	_, err := w.Write(line)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte("\n"))
+	return err
+}
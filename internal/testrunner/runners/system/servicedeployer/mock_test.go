@@ -0,0 +1,91 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "events.json"), `{"events":[]}`)
+
+	log := new(mockLog)
+	handler, err := newMockHandler(dir, []mockRouteConfig{
+		{
+			Path:     "/api/events",
+			Methods:  []string{"GET"},
+			Headers:  map[string]string{"Content-Type": "application/json"},
+			BodyFile: "events.json",
+		},
+		{
+			Path:       "/api/events",
+			StatusCode: http.StatusCreated,
+		},
+	}, log)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events?page=1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.Equal(t, `{"events":[]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/events", nil))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.Contains(t, string(log.Bytes()), "GET /unknown: 404 (no matching route)")
+}
+
+func TestMockReplay_file(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "sample.log"), "first\nsecond\n")
+
+	var ctxt ServiceContext
+	ctxt.Logs.Folder.Local = dir
+
+	config := mockReplayConfig{
+		File:     "sample.log",
+		Protocol: replayProtocolFile,
+		Path:     "access.log",
+	}
+	require.NoError(t, config.validate())
+
+	replay, err := newMockReplay(dir, config, ctxt)
+	require.NoError(t, err)
+
+	err = replay.run(context.Background(), new(mockLog))
+	require.NoError(t, err)
+
+	replayed, err := ioutil.ReadFile(filepath.Join(dir, "access.log"))
+	require.NoError(t, err)
+	require.Equal(t, "first\nsecond\n", string(replayed))
+}
+
+func TestMockReplayConfig_validate(t *testing.T) {
+	require.Error(t, mockReplayConfig{File: "sample.log", Protocol: "udp"}.validate())
+	require.Error(t, mockReplayConfig{File: "sample.log", Protocol: "file"}.validate())
+	require.Error(t, mockReplayConfig{File: "sample.log", Protocol: "http"}.validate())
+	require.NoError(t, mockReplayConfig{File: "sample.log", Protocol: "tcp", Port: 9000}.validate())
+}
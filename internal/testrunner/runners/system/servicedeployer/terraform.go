@@ -5,8 +5,11 @@
 package servicedeployer
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -16,15 +19,36 @@ import (
 	"github.com/elastic/elastic-package/internal/logger"
 )
 
+const (
+	terraformOutputsFile = "outputs.json"
+	terraformEnvFile     = "terraform.env"
+)
+
 // TerraformServiceDeployer is responsible for deploying infrastructure described with Terraform definitions.
 type TerraformServiceDeployer struct {
 	definitionsDir string
+	readyTimeout   time.Duration
+	keepState      bool
 }
 
-// NewTerraformServiceDeployer creates an instance of TerraformServiceDeployer.
-func NewTerraformServiceDeployer(definitionsDir string) (*TerraformServiceDeployer, error) {
+type terraformDeployedService struct {
+	dockerComposeDeployedService
+
+	workspaceDir string
+	keepState    bool
+}
+
+// NewTerraformServiceDeployer creates an instance of TerraformServiceDeployer. The deployer waits for applied
+// definitions up to the ready timeout (DefaultServiceReadyTimeout, if not set). If keepState is enabled, the Terraform
+// workspace (definitions and state) isn't removed once the service is torn down.
+func NewTerraformServiceDeployer(definitionsDir string, readyTimeout time.Duration, keepState bool) (*TerraformServiceDeployer, error) {
+	if readyTimeout == 0 {
+		readyTimeout = DefaultServiceReadyTimeout
+	}
 	return &TerraformServiceDeployer{
 		definitionsDir: definitionsDir,
+		readyTimeout:   readyTimeout,
+		keepState:      keepState,
 	}, nil
 }
 
@@ -37,48 +61,117 @@ func (tsd TerraformServiceDeployer) SetUp(inCtxt ServiceContext) (DeployedServic
 		return nil, errors.Wrap(err, "can't load docker compose definitions")
 	}
 
-	service := dockerComposeDeployedService{
-		ymlPaths: ymlPaths,
-		project:  "elastic-package-service",
+	workspaceDir, err := tsd.createWorkspace(inCtxt)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't create Terraform workspace")
+	}
+
+	service := terraformDeployedService{
+		dockerComposeDeployedService: dockerComposeDeployedService{
+			ymlPaths: ymlPaths,
+			project:  "elastic-package-service",
+			env:      tsd.buildTerraformExecutorEnvironment(inCtxt, workspaceDir),
+		},
+		workspaceDir: workspaceDir,
+		keepState:    tsd.keepState,
 	}
 	outCtxt := inCtxt
 
 	p, err := compose.NewProject(service.project, service.ymlPaths...)
 	if err != nil {
+		service.removeWorkspaceOnFailure()
 		return nil, errors.Wrap(err, "could not create docker compose project for service")
 	}
 
 	// Clean service logs
 	err = files.RemoveContent(outCtxt.Logs.Folder.Local)
 	if err != nil {
+		service.removeWorkspaceOnFailure()
 		return nil, errors.Wrap(err, "removing service logs failed")
 	}
 
 	// Set custom aliases, which may be used in agent policies.
-	serviceComposeConfig, err := p.Config(compose.CommandOptions{})
+	serviceComposeConfig, err := p.Config(compose.CommandOptions{Env: service.env})
 	if err != nil {
+		service.removeWorkspaceOnFailure()
 		return nil, errors.Wrap(err, "could not get Docker Compose configuration for service")
 	}
 	outCtxt.CustomProperties, err = buildTerraformAliases(serviceComposeConfig)
 	if err != nil {
+		service.removeWorkspaceOnFailure()
 		return nil, errors.Wrap(err, "can't build terraform aliases")
 	}
 
 	// Boot up service
-	tfEnvironment := tsd.buildTerraformExecutorEnvironment(inCtxt)
 	opts := compose.CommandOptions{
-		Env:       tfEnvironment,
+		Env:       service.env,
 		ExtraArgs: []string{"--build", "-d"},
 	}
+	service.ctxt = outCtxt
 	if err := p.Up(opts); err != nil {
+		service.tearDownOnFailure()
 		return nil, errors.Wrap(err, "could not boot up service using docker compose")
 	}
 
+	// The executor becomes healthy once definitions are applied and outputs are stored.
+	err = waitForHealthyContainers(p, compose.CommandOptions{Env: service.env}, tsd.readyTimeout)
+	if err != nil {
+		service.tearDownOnFailure()
+		return nil, errors.Wrap(err, "Terraform definitions are not applied")
+	}
+
+	outputs, err := readTerraformOutputs(filepath.Join(workspaceDir, terraformOutputsFile))
+	if err != nil {
+		service.tearDownOnFailure()
+		return nil, errors.Wrap(err, "can't read Terraform outputs")
+	}
+	for name, value := range outputs {
+		outCtxt.CustomProperties[name] = value
+	}
+
 	outCtxt.Agent.Host.NamePrefix = "docker-fleet-agent"
 	service.ctxt = outCtxt
 	return &service, nil
 }
 
+// TearDown stops the Terraform executor, which destroys the infrastructure, and removes the Terraform workspace.
+// If the state should be kept, neither the infrastructure is destroyed nor the workspace is removed.
+func (s *terraformDeployedService) TearDown() error {
+	err := s.dockerComposeDeployedService.TearDown()
+	if err != nil {
+		return err
+	}
+	return s.removeWorkspace()
+}
+
+func (s *terraformDeployedService) removeWorkspace() error {
+	if s.keepState {
+		logger.Infof("Terraform workspace kept, infrastructure has not been destroyed (path: %s)", s.workspaceDir)
+		return nil
+	}
+
+	err := os.RemoveAll(s.workspaceDir)
+	if err != nil {
+		return errors.Wrapf(err, "can't remove Terraform workspace (path: %s)", s.workspaceDir)
+	}
+	return nil
+}
+
+// removeWorkspaceOnFailure removes the workspace if the service couldn't be set up.
+func (s *terraformDeployedService) removeWorkspaceOnFailure() {
+	if err := s.removeWorkspace(); err != nil {
+		logger.Errorf("can't remove Terraform workspace: %v", err)
+	}
+}
+
+// tearDownOnFailure tears down the partially started service if it couldn't be set up.
+func (s *terraformDeployedService) tearDownOnFailure() {
+	if err := s.TearDown(); err != nil {
+		logger.Errorf("can't tear down Terraform service: %v", err)
+		s.removeWorkspaceOnFailure()
+	}
+}
+
 func (tsd TerraformServiceDeployer) loadComposeDefinitions() ([]string, error) {
 	locationManager, err := locations.NewLocationManager()
 	if err != nil {
@@ -100,4 +193,51 @@ func (tsd TerraformServiceDeployer) loadComposeDefinitions() ([]string, error) {
 	}, nil
 }
 
+// createWorkspace creates the directory mounted as the Terraform working directory, dedicated to the test run.
+// It also contains the file with environment variables passed to the Terraform executor.
+func (tsd TerraformServiceDeployer) createWorkspace(ctxt ServiceContext) (string, error) {
+	locationManager, err := locations.NewLocationManager()
+	if err != nil {
+		return "", errors.Wrap(err, "can't locate Terraform state directory")
+	}
+
+	runID := ctxt.Test.RunID
+	if runID == "" {
+		runID = "detached"
+	}
+
+	workspaceDir := filepath.Join(locationManager.TerraformStateDir(), runID)
+	err = os.MkdirAll(workspaceDir, 0755)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't create directory (path: %s)", workspaceDir)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(workspaceDir, terraformEnvFile), buildTerraformEnvFile(os.Environ()), 0600)
+	if err != nil {
+		return "", errors.Wrap(err, "can't write environment file")
+	}
+	return workspaceDir, nil
+}
+
+func readTerraformOutputs(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't read file (path: %s)", path)
+	}
+
+	var outputs map[string]struct {
+		Value interface{} `json:"value"`
+	}
+	err = json.Unmarshal(data, &outputs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't unmarshal Terraform outputs (path: %s)", path)
+	}
+
+	m := map[string]interface{}{}
+	for name, output := range outputs {
+		m[name] = output.Value
+	}
+	return m, nil
+}
+
 var _ ServiceDeployer = new(TerraformServiceDeployer)
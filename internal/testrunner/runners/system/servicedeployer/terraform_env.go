@@ -5,25 +5,36 @@
 package servicedeployer
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/elastic/elastic-package/internal/compose"
 )
 
 const (
-	tfDir       = "TF_DIR"
-	tfTestRunID = "TF_VAR_TEST_RUN_ID"
+	tfDir          = "TF_DIR"
+	tfEnvFile      = "TF_ENV_FILE"
+	tfWorkspaceDir = "TF_WORKSPACE_DIR"
+	tfTestRunID    = "TF_VAR_TEST_RUN_ID"
+	tfKeepState    = "TF_KEEP_STATE"
+
+	tfVarPrefix = "TF_VAR_"
 
 	envYmlFile = "env.yml"
 )
 
-func (tsd TerraformServiceDeployer) buildTerraformExecutorEnvironment(ctxt ServiceContext) []string {
+func (tsd TerraformServiceDeployer) buildTerraformExecutorEnvironment(ctxt ServiceContext, workspaceDir string) []string {
 	vars := map[string]string{}
 	vars[serviceLogsDirEnv] = ctxt.Logs.Folder.Local
 	vars[tfTestRunID] = ctxt.Test.RunID
 	vars[tfDir] = tsd.definitionsDir
+	vars[tfWorkspaceDir] = workspaceDir
+	vars[tfEnvFile] = filepath.Join(workspaceDir, terraformEnvFile)
+	vars[tfKeepState] = strconv.FormatBool(tsd.keepState)
 
 	var pairs []string
 	for k, v := range vars {
@@ -32,6 +43,20 @@ func (tsd TerraformServiceDeployer) buildTerraformExecutorEnvironment(ctxt Servi
 	return pairs
 }
 
+// buildTerraformEnvFile passes Terraform variables (TF_VAR_*) defined in the environment of elastic-package to
+// the Terraform executor, e.g. to override endpoints of providers. The test run ID can't be overridden.
+func buildTerraformEnvFile(environ []string) []byte {
+	var b bytes.Buffer
+	for _, pair := range environ {
+		if !strings.HasPrefix(pair, tfVarPrefix) || strings.HasPrefix(pair, tfTestRunID+"=") {
+			continue
+		}
+		b.WriteString(pair)
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
 func buildTerraformAliases(serviceComposeConfig *compose.Config) (map[string]interface{}, error) {
 	terraformService, found := serviceComposeConfig.Services["terraform"]
 	if !found {
@@ -41,7 +66,7 @@ func buildTerraformAliases(serviceComposeConfig *compose.Config) (map[string]int
 	m := map[string]interface{}{}
 	for name, value := range terraformService.Environment {
 		// skip empty values and internal Terraform variables
-		if value != "" && !strings.HasPrefix(name, "TF_VAR_") {
+		if value != "" && !strings.HasPrefix(name, tfVarPrefix) && name != tfKeepState {
 			m[name] = value
 		}
 	}
@@ -0,0 +1,43 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package servicedeployer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTerraformEnvFile(t *testing.T) {
+	envFile := buildTerraformEnvFile([]string{
+		"HOME=/home/user",
+		"TF_VAR_TEST_RUN_ID=12345",
+		"TF_VAR_AWS_ENDPOINT=http://localstack:4566",
+		"TF_LOG=DEBUG",
+	})
+	require.Equal(t, "TF_VAR_AWS_ENDPOINT=http://localstack:4566\n", string(envFile))
+}
+
+func TestReadTerraformOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, terraformOutputsFile)
+	writeFile(t, path, `{
+  "bucket_name": {"sensitive": false, "type": "string", "value": "elastic-package-test-12345"},
+  "queue_urls": {"sensitive": false, "type": ["list", "string"], "value": ["https://sqs/1", "https://sqs/2"]}
+}`)
+
+	outputs, err := readTerraformOutputs(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"bucket_name": "elastic-package-test-12345",
+		"queue_urls":  []interface{}{"https://sqs/1", "https://sqs/2"},
+	}, outputs)
+}
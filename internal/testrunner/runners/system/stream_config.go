@@ -0,0 +1,106 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/kylelemons/godebug/diff"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+const expectedStreamConfigSuffix = "-expected-stream.yml"
+
+type fullAgentPolicy struct {
+	Inputs []struct {
+		Type    string          `json:"type"`
+		Streams []common.MapStr `json:"streams"`
+	} `json:"inputs"`
+}
+
+func (r *runner) verifyStreamConfig(kib *kibana.Client, policy kibana.Policy, config testConfig, tds testedDataStream) error {
+	fullPolicy, err := kib.GetFullPolicy(policy.ID)
+	if err != nil {
+		return errors.Wrap(err, "could not read the full agent policy")
+	}
+
+	actual, err := renderedStreamConfig(fullPolicy, tds.packageDataStream)
+	if err != nil {
+		return errors.Wrap(err, "can't find rendered stream configuration in the agent policy")
+	}
+
+	expectedPath := expectedFilePath(config.Path, expectedStreamConfigSuffix)
+	if r.options.GenerateTestResult {
+		err := ioutil.WriteFile(expectedPath, actual, 0644)
+		if err != nil {
+			return errors.Wrap(err, "writing expected stream configuration failed")
+		}
+	}
+
+	expected, err := ioutil.ReadFile(expectedPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading expected stream configuration failed (path: %s)", expectedPath)
+	}
+
+	// Normalize the expected snippet, so formatting differences aren't reported.
+	var m common.MapStr
+	err = yaml.Unmarshal(expected, &m)
+	if err != nil {
+		return errors.Wrapf(err, "unmarshalling expected stream configuration failed (path: %s)", expectedPath)
+	}
+	expected, err = yaml.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "marshalling expected stream configuration failed")
+	}
+
+	report := diff.Diff(string(expected), string(actual))
+	if report != "" {
+		return testrunner.ErrTestCaseFailed{
+			Reason:  "Rendered stream configuration is different from the expected one",
+			Details: report,
+		}
+	}
+	return nil
+}
+
+// renderedStreamConfig finds the stream of the tested data stream in the full agent policy and returns
+// its configuration in the YAML form. The stream ID is skipped as it depends on the package policy ID.
+func renderedStreamConfig(fullPolicy json.RawMessage, ds kibana.PackageDataStream) ([]byte, error) {
+	var p fullAgentPolicy
+	err := json.Unmarshal(fullPolicy, &p)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling full agent policy failed")
+	}
+
+	input := ds.Inputs[0]
+	dataset := input.Streams[0].DataStream.Dataset
+	for _, pi := range p.Inputs {
+		if pi.Type != input.Type {
+			continue
+		}
+
+		for _, stream := range pi.Streams {
+			v, err := stream.GetValue("data_stream.dataset")
+			if err != nil || v != dataset {
+				continue
+			}
+
+			delete(stream, "id")
+			body, err := yaml.Marshal(stream)
+			if err != nil {
+				return nil, errors.Wrap(err, "marshalling stream configuration failed")
+			}
+			return body, nil
+		}
+	}
+	return nil, fmt.Errorf("stream not found (input: %s, dataset: %s)", input.Type, dataset)
+}
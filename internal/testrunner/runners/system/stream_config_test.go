@@ -0,0 +1,74 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/kibana"
+)
+
+const fullAgentPolicyJSON = `{
+  "id": "1234",
+  "inputs": [
+    {
+      "type": "apache/metrics",
+      "streams": [
+        {
+          "id": "apache/metrics-apache.status-5678",
+          "data_stream": {"dataset": "apache.status", "type": "metrics"},
+          "hosts": ["http://localhost"]
+        }
+      ]
+    },
+    {
+      "type": "logfile",
+      "streams": [
+        {
+          "id": "logfile-apache.error-5678",
+          "data_stream": {"dataset": "apache.error", "type": "logs"},
+          "paths": ["/var/log/error.log"]
+        },
+        {
+          "id": "logfile-apache.access-5678",
+          "data_stream": {"dataset": "apache.access", "type": "logs"},
+          "paths": ["/var/log/access.log"],
+          "exclude_files": [".gz$"]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestRenderedStreamConfig(t *testing.T) {
+	ds := kibana.PackageDataStream{
+		Inputs: []kibana.Input{
+			{
+				Type: "logfile",
+				Streams: []kibana.Stream{
+					{DataStream: kibana.DataStream{Type: "logs", Dataset: "apache.access"}},
+				},
+			},
+		},
+	}
+
+	actual, err := renderedStreamConfig(json.RawMessage(fullAgentPolicyJSON), ds)
+	require.NoError(t, err)
+	require.Equal(t, `data_stream:
+    dataset: apache.access
+    type: logs
+exclude_files:
+    - .gz$
+paths:
+    - /var/log/access.log
+`, string(actual))
+
+	ds.Inputs[0].Streams[0].DataStream.Dataset = "apache.missing"
+	_, err = renderedStreamConfig(json.RawMessage(fullAgentPolicyJSON), ds)
+	require.Error(t, err)
+}
@@ -9,6 +9,8 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/aymerick/raymond"
 	"github.com/pkg/errors"
@@ -16,6 +18,8 @@ import (
 	"github.com/elastic/go-ucfg"
 	"github.com/elastic/go-ucfg/yaml"
 
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/testrunner"
 	"github.com/elastic/elastic-package/internal/testrunner/runners/system/servicedeployer"
@@ -27,8 +31,14 @@ type testConfig struct {
 	testrunner.SkippableConfig `config:",inline"`
 
 	Input               string `config:"input"`
+	PolicyTemplate      string `config:"policy_template"` // Name of the policy template to test, the first one by default.
+	Deployer            string `config:"deployer"`        // Name of the service deployer, required if multiple ones are defined.
 	Service             string `config:"service"`
 	ServiceNotifySignal string `config:"service_notify_signal"` // Signal to send when the agent policy is applied.
+	ServicePodSelector  string `config:"service_pod_selector"`  // Label selector of Kubernetes pods running the service.
+
+	// ServiceReadyTimeout limits the time to wait for healthy service containers.
+	ServiceReadyTimeout time.Duration `config:"service_ready_timeout"`
 
 	Vars       map[string]packages.VarValue `config:"vars"`
 	DataStream struct {
@@ -39,9 +49,100 @@ type testConfig struct {
 	// type but can be ingested as numeric type.
 	NumericKeywordFields []string `config:"numeric_keyword_fields"`
 
+	// Agents selects the enrolled agents the test policy is assigned to.
+	Agents agentsConfig `config:"agents"`
+
+	// DataStreams lists additional data streams of the package, which are enabled
+	// in the same test policy. Their documents are validated separately.
+	DataStreams []additionalDataStreamConfig `config:"data_streams"`
+
+	// VerifyStreamConfig enables comparing the stream configuration, as rendered in the full
+	// agent policy, with the test-<name>-expected-stream.yml file.
+	VerifyStreamConfig bool `config:"verify_stream_config"`
+
+	// ExpectedDocuments enables comparing the normalized documents with the
+	// test-<name>-expected.json file.
+	ExpectedDocuments expectedDocumentsConfig `config:"expected_documents"`
+
 	Path string
 }
 
+type expectedDocumentsConfig struct {
+	Enabled bool `config:"enabled"`
+
+	// RemoveFields holds a list of fields that are stripped from documents, in addition
+	// to the default ones, before comparing them with expected documents.
+	RemoveFields []string `config:"remove_fields"`
+}
+
+type agentsConfig struct {
+	// HostNamePrefix overrides the host name prefix of agents provided by the service deployer.
+	HostNamePrefix string `config:"host_name_prefix"`
+
+	// PolicyID selects agents enrolled with the agent policy.
+	PolicyID string `config:"policy_id"`
+
+	// Tags selects agents having all of the tags.
+	Tags []string `config:"tags"`
+
+	// Count is the number of agents the test policy is assigned to (1 by default).
+	Count int `config:"count"`
+}
+
+// matches checks if the agent is selected by all defined criteria.
+func (a agentsConfig) matches(agent kibana.Agent) bool {
+	if a.HostNamePrefix != "" && !strings.HasPrefix(agent.LocalMetadata.Host.Name, a.HostNamePrefix) {
+		return false
+	}
+	if a.PolicyID != "" && agent.PolicyID != a.PolicyID {
+		return false
+	}
+	for _, tag := range a.Tags {
+		if !common.StringSliceContains(agent.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a agentsConfig) count() int {
+	if a.Count < 1 {
+		return 1
+	}
+	return a.Count
+}
+
+type additionalDataStreamConfig struct {
+	Name  string                       `config:"name" validate:"required"`
+	Input string                       `config:"input"`
+	Vars  map[string]packages.VarValue `config:"vars"`
+
+	// NumericKeywordFields holds a list of fields that have keyword
+	// type but can be ingested as numeric type.
+	NumericKeywordFields []string `config:"numeric_keyword_fields"`
+}
+
+// testConfig returns the configuration used to add the additional data stream to the test policy.
+// Package-level vars are shared with the main test configuration.
+func (a additionalDataStreamConfig) testConfig(main testConfig) testConfig {
+	var c testConfig
+	c.Input = a.Input
+	c.PolicyTemplate = main.PolicyTemplate
+	c.Vars = main.Vars
+	c.DataStream.Vars = a.Vars
+	c.NumericKeywordFields = a.NumericKeywordFields
+	return c
+}
+
+func (t testConfig) additionalDataStream(name string) additionalDataStreamConfig {
+	for _, ds := range t.DataStreams {
+		if ds.Name == name {
+			return ds
+		}
+	}
+	return additionalDataStreamConfig{Name: name}
+}
+
 func (t testConfig) Name() string {
 	name := filepath.Base(t.Path)
 	if matches := systemTestConfigFilePattern.FindStringSubmatch(name); len(matches) > 1 {
@@ -0,0 +1,166 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kylelemons/godebug/diff"
+	"github.com/pkg/errors"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+const expectedTestResultSuffix = "-expected.json"
+
+// defaultRemovedFields are fields that differ between test runs regardless of the
+// tested integration (agent metadata, timestamps, identifiers), so they are always
+// stripped from documents before comparing them with expected ones.
+var defaultRemovedFields = []string{
+	"@timestamp",
+	"agent",
+	"elastic_agent",
+	"host",
+	"event.created",
+	"event.ingested",
+	"event.id",
+}
+
+type testResultDefinition struct {
+	Expected []json.RawMessage `json:"expected"`
+}
+
+// normalizeDocuments strips the default and configured fields from the documents and
+// sorts them, so the output is deterministic regardless of the ingestion order.
+func normalizeDocuments(docs []common.MapStr, config expectedDocumentsConfig) ([]json.RawMessage, error) {
+	var removedFields []string
+	removedFields = append(removedFields, defaultRemovedFields...)
+	removedFields = append(removedFields, config.RemoveFields...)
+
+	var normalized []json.RawMessage
+	for _, doc := range docs {
+		// Operate on a copy, so the original documents stay intact for further validation.
+		var m common.MapStr
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't marshal document")
+		}
+		err = json.Unmarshal(b, &m)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't unmarshal document")
+		}
+
+		for _, key := range removedFields {
+			err := m.Delete(key)
+			if err != nil && err != common.ErrKeyNotFound {
+				return nil, errors.Wrapf(err, "can't remove field (key: %s)", key)
+			}
+		}
+
+		b, err = json.Marshal(&m)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't marshal normalized document")
+		}
+		normalized = append(normalized, b)
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		return string(normalized[i]) < string(normalized[j])
+	})
+	return normalized, nil
+}
+
+func writeTestResult(configPath string, docs []json.RawMessage) error {
+	data, err := marshalTestResultDefinition(docs)
+	if err != nil {
+		return errors.Wrap(err, "marshalling test result failed")
+	}
+
+	err = ioutil.WriteFile(expectedFilePath(configPath, expectedTestResultSuffix), data, 0644)
+	if err != nil {
+		return errors.Wrap(err, "writing test result failed")
+	}
+	return nil
+}
+
+func readExpectedTestResult(configPath string) ([]json.RawMessage, error) {
+	path := expectedFilePath(configPath, expectedTestResultSuffix)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading test result file failed (path: %s)", path)
+	}
+
+	var trd testResultDefinition
+	err = json.Unmarshal(data, &trd)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling expected test result failed")
+	}
+	return trd.Expected, nil
+}
+
+func compareResults(configPath string, docs []json.RawMessage) error {
+	actual, err := marshalTestResultDefinition(docs)
+	if err != nil {
+		return errors.Wrap(err, "marshalling actual test results failed")
+	}
+
+	expectedDocs, err := readExpectedTestResult(configPath)
+	if err != nil {
+		return errors.Wrap(err, "reading expected test result failed")
+	}
+
+	expected, err := marshalTestResultDefinition(expectedDocs)
+	if err != nil {
+		return errors.Wrap(err, "marshalling expected test results failed")
+	}
+
+	report := diff.Diff(string(expected), string(actual))
+	if report != "" {
+		return testrunner.ErrTestCaseFailed{
+			Reason:  "Expected documents are different from actual ones",
+			Details: report,
+		}
+	}
+	return nil
+}
+
+func marshalTestResultDefinition(docs []json.RawMessage) ([]byte, error) {
+	var trd testResultDefinition
+	trd.Expected = docs
+	body, err := json.MarshalIndent(&trd, "", "    ")
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling test result definition failed")
+	}
+	return body, nil
+}
+
+// countExpectedDocuments returns the number of expected documents, or 0 if the golden file
+// hasn't been generated yet.
+func countExpectedDocuments(configPath string) (int, error) {
+	docs, err := readExpectedTestResult(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+// expectedFilePath returns the path of the golden file with the given suffix for the system test
+// configuration file, e.g. test-default-config.yml -> test-default-expected.json.
+func expectedFilePath(configPath, suffix string) string {
+	name := filepath.Base(configPath)
+	if matches := systemTestConfigFilePattern.FindStringSubmatch(name); len(matches) > 1 {
+		name = fmt.Sprintf("test-%s", matches[1])
+	}
+	return filepath.Join(filepath.Dir(configPath), name+suffix)
+}
@@ -0,0 +1,50 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package system
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+func TestNormalizeDocuments(t *testing.T) {
+	docs := []common.MapStr{
+		{
+			"@timestamp": "2021-06-10T10:00:01.000Z",
+			"agent":      common.MapStr{"id": "abc"},
+			"event":      common.MapStr{"ingested": "2021-06-10T10:00:02.000Z", "kind": "event"},
+			"message":    "second",
+			"source":     common.MapStr{"port": 1234},
+		},
+		{
+			"@timestamp": "2021-06-10T10:00:00.000Z",
+			"host":       common.MapStr{"name": "docker-fleet-agent"},
+			"message":    "first",
+		},
+	}
+
+	normalized, err := normalizeDocuments(docs, expectedDocumentsConfig{
+		Enabled:      true,
+		RemoveFields: []string{"source.port"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []json.RawMessage{
+		json.RawMessage(`{"event":{"kind":"event"},"message":"second","source":{}}`),
+		json.RawMessage(`{"message":"first"}`),
+	}, normalized)
+
+	// Original documents must stay intact.
+	_, err = docs[0].GetValue("source.port")
+	require.NoError(t, err)
+}
+
+func TestExpectedFilePath(t *testing.T) {
+	require.Equal(t, "system/test-default-expected.json", expectedFilePath("system/test-default-config.yml", expectedTestResultSuffix))
+	require.Equal(t, "system/test-default-expected-stream.yml", expectedFilePath("system/test-default-config.yml", expectedStreamConfigSuffix))
+}
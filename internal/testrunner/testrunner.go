@@ -20,6 +20,7 @@ import (
 
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/multierror"
+	"github.com/elastic/elastic-package/internal/profile"
 )
 
 // TestType represents the various supported test types
@@ -33,6 +34,19 @@ type TestOptions struct {
 	ESClient           *elasticsearch.Client
 
 	DeferCleanup time.Duration
+
+	// AlwaysCollectDiagnostics enables collecting diagnostics also for passing test cases.
+	AlwaysCollectDiagnostics bool
+
+	// KeepTerraformState disables removing Terraform workspaces (definitions and state) of system tests.
+	KeepTerraformState bool
+
+	// StackVersion is the version of the Elastic stack tests are run against. It's set only
+	// if tests are run against multiple stack versions.
+	StackVersion string
+
+	// Profile is the profile of the Elastic stack tests are run against. It's set only for tests requiring the stack.
+	Profile *profile.Profile
 }
 
 // TestRunner is the interface all test runners must implement.
@@ -53,6 +67,9 @@ type TestRunner interface {
 	CanRunPerDataStream() bool
 
 	TestFolderRequired() bool
+
+	// StackRequired returns whether the test runner needs a running Elastic stack.
+	StackRequired() bool
 }
 
 var runners = map[TestType]TestRunner{}
@@ -90,6 +107,14 @@ type TestResult struct {
 	// If the test was skipped, the reason it was skipped and a link for more
 	// details.
 	Skipped *SkipConfig
+
+	// Path to the directory with diagnostics (e.g. logs, policies, documents) collected
+	// while running the test case. Optional.
+	DiagnosticsPath string
+
+	// Version of the Elastic stack the test case was run against. Optional, set only
+	// if tests were run against multiple stack versions.
+	StackVersion string
 }
 
 // ResultComposer wraps a TestResult and provides convenience methods for
@@ -256,7 +281,7 @@ func Run(testType TestType, options TestOptions) ([]TestResult, error) {
 	}
 
 	// Handle signals, incl. ctrl+c
-	ch := make(chan os.Signal)
+	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-ch